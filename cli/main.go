@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"prabandh/database"
+	"prabandh/duplicates"
 	"prabandh/indexer"
+	"prabandh/keywords"
+	"prabandh/llm"
+	"prabandh/llm/prompt"
 	"prabandh/models"
+	"prabandh/search"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -33,6 +40,8 @@ func initialModel(verbose bool) model {
 			"Search Files and Summaries",
 			"View Whitelisted Directories",
 			"View Blacklisted Directories",
+			"View Keyword Cloud",
+			"Find Duplicate Files",
 			"Toggle Verbose Mode",
 			"Exit",
 		},
@@ -94,6 +103,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.operation = "view_whitelisted"
 			case "View Blacklisted Directories":
 				m.operation = "view_blacklisted"
+			case "View Keyword Cloud":
+				m.operation = "view_keyword_cloud"
+			case "Find Duplicate Files":
+				m.operation = "find_duplicates"
 			case "Toggle Verbose Mode":
 				m.verbose = !m.verbose
 				if m.verbose {
@@ -167,8 +180,22 @@ func (m model) handleOperation(operation, input string) string {
 		}
 
 		// Index files
-		indexer := indexer.NewFileIndexer("http://localhost:5051", "gemma:2b", m.verbose)
-		indexer.IndexDirectory(dirPath)
+		prompts, err := prompt.LoadFromEnv()
+		if err != nil {
+			return fmt.Sprintf("Error loading prompt templates: %v", err)
+		}
+		provider, err := llm.NewFromEnv()
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		config, err := indexer.ConfigFromEnv()
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		indexer := indexer.NewFileIndexer(provider, prompts, config, m.verbose)
+		if err := indexer.IndexDirectory(context.Background(), dirPath); err != nil {
+			return fmt.Sprintf("Error indexing directory: %v", err)
+		}
 
 		return fmt.Sprintf("Successfully indexed directory: %s", dirPath)
 
@@ -206,38 +233,60 @@ func (m model) handleOperation(operation, input string) string {
 			return "Error: Search query cannot be empty"
 		}
 
-		var result strings.Builder
+		parsed, err := search.ParseQuery(query)
+		if err != nil {
+			return fmt.Sprintf("Error: Invalid search query: %v", err)
+		}
+
+		provider, err := llm.NewFromEnv()
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		searcher := search.NewSearcher(database.DB, provider)
+		page, err := searcher.Search(context.Background(), parsed, search.Options{Limit: 50})
+		if err != nil {
+			return fmt.Sprintf("Error searching: %v", err)
+		}
 
-		// Search files by name
-		var files []models.FileIndex
-		database.DB.Where("file_name LIKE ?", "%"+query+"%").Find(&files)
-		if len(files) > 0 {
-			result.WriteString("Matching Files:\n")
-			for _, file := range files {
-				result.WriteString(fmt.Sprintf("- %s (%s)\n", file.FileName, file.FilePath))
+		// Retry with typo tolerance before giving up
+		if len(page.Results) == 0 {
+			page, err = searcher.Search(context.Background(), parsed, search.Options{Limit: 50, Fuzzy: true})
+			if err != nil {
+				return fmt.Sprintf("Error searching: %v", err)
 			}
-		} else {
-			result.WriteString("No matching files found\n")
 		}
+		results := page.Results
 
-		// Search by keywords
+		var didYouMean string
+		if len(page.Suggestions) > 0 {
+			didYouMean = fmt.Sprintf("Did you mean: %s\n", strings.Join(page.Suggestions, " | "))
+		}
+
+		if len(results) == 0 {
+			return didYouMean + "No matching files found"
+		}
+
+		ids := make([]uint, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+
+		// Show the keywords of each matching file
 		var summaries []models.FileSummary
-		database.DB.Where("summary_keyword LIKE ?", "%"+query+"%").Find(&summaries)
-
-		if len(summaries) > 0 {
-			result.WriteString("\nMatching Keywords:\n")
-			fileKeywords := make(map[string][]string)
-			for _, summary := range summaries {
-				var file models.FileIndex
-				database.DB.First(&file, summary.FileIndexID)
-				fileKeywords[file.FileName] = append(fileKeywords[file.FileName], summary.SummaryKeyword)
-			}
+		database.DB.Where("file_index_id IN ?", ids).Find(&summaries)
+		fileKeywords := make(map[uint][]string)
+		for _, summary := range summaries {
+			fileKeywords[summary.FileIndexID] = append(fileKeywords[summary.FileIndexID], summary.SummaryKeyword)
+		}
 
-			for fileName, keywords := range fileKeywords {
-				result.WriteString(fmt.Sprintf("- %s: %s\n", fileName, strings.Join(keywords, ", ")))
+		var result strings.Builder
+		result.WriteString(didYouMean)
+		result.WriteString("Matching Files:\n")
+		for _, r := range results {
+			result.WriteString(fmt.Sprintf("- %s (%s)\n", r.FileName, r.FilePath))
+			if keywords := fileKeywords[r.ID]; len(keywords) > 0 {
+				result.WriteString(fmt.Sprintf("    %s\n", strings.Join(keywords, ", ")))
 			}
-		} else {
-			result.WriteString("\nNo matching keywords found\n")
 		}
 
 		return result.String()
@@ -272,10 +321,116 @@ func (m model) handleOperation(operation, input string) string {
 			result.WriteString(fmt.Sprintf("- %s\n", dir.DirectoryLocation))
 		}
 		return result.String()
+
+	case "view_keyword_cloud":
+		top, err := keywords.NewStore(database.DB).Top(40, nil)
+		if err != nil {
+			return fmt.Sprintf("Error loading keywords: %v", err)
+		}
+		if len(top) == 0 {
+			return "No keywords found"
+		}
+		return "Keyword Cloud:\n\n" + renderKeywordCloud(top)
+
+	case "find_duplicates":
+		finder := duplicates.NewFinder(database.DB)
+		exact, err := finder.Exact()
+		if err != nil {
+			return fmt.Sprintf("Error finding duplicates: %v", err)
+		}
+		near, err := finder.Near(duplicates.DefaultMaxDistance)
+		if err != nil {
+			return fmt.Sprintf("Error finding near duplicates: %v", err)
+		}
+		if len(exact.Groups) == 0 && len(near.Groups) == 0 {
+			return "No duplicate files found"
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("Identical Files (%s wasted):\n", formatBytes(exact.TotalWastedBytes)))
+		for i, group := range exact.Groups {
+			if i == 10 {
+				result.WriteString(fmt.Sprintf("  ... and %d more groups\n", len(exact.Groups)-i))
+				break
+			}
+			result.WriteString(fmt.Sprintf("- %d copies, %s wasted\n", len(group.Files), formatBytes(group.WastedBytes)))
+			for _, file := range group.Files {
+				result.WriteString(fmt.Sprintf("    %s\n", file.FilePath))
+			}
+		}
+
+		result.WriteString("\nWasted Space by Directory:\n")
+		for i, dir := range exact.Directories {
+			if i == 10 {
+				break
+			}
+			result.WriteString(fmt.Sprintf("- %s (%d copies, %s)\n", dir.Directory, dir.Copies, formatBytes(dir.WastedBytes)))
+		}
+
+		result.WriteString(fmt.Sprintf("\nNear-Duplicate Text Files (%d groups):\n", len(near.Groups)))
+		for i, group := range near.Groups {
+			if i == 10 {
+				result.WriteString(fmt.Sprintf("  ... and %d more groups\n", len(near.Groups)-i))
+				break
+			}
+			result.WriteString(fmt.Sprintf("- %d files, distance %d\n", len(group.Files), group.Distance))
+			for _, file := range group.Files {
+				result.WriteString(fmt.Sprintf("    %s\n", file.FilePath))
+			}
+		}
+		return result.String()
 	}
 	return "Invalid operation"
 }
 
+// formatBytes renders a byte count using binary units, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// renderKeywordCloud lays keywords out alphabetically, styling the most frequent ones more prominently.
+func renderKeywordCloud(counts []keywords.Count) string {
+	const width = 72
+
+	maxCount := counts[0].Count
+	sorted := append([]keywords.Count(nil), counts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Keyword < sorted[j].Keyword })
+
+	tiers := []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("12")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true),
+	}
+
+	var b strings.Builder
+	lineLen := 0
+	for _, kc := range sorted {
+		tier := int(float64(kc.Count) / float64(maxCount) * float64(len(tiers)-1))
+		word := fmt.Sprintf("%s(%d)", kc.Keyword, kc.Count)
+		if lineLen > 0 && lineLen+len(word)+2 > width {
+			b.WriteString("\n")
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			b.WriteString("  ")
+			lineLen += 2
+		}
+		b.WriteString(tiers[tier].Render(word))
+		lineLen += len(word)
+	}
+	return b.String()
+}
+
 func main() {
 	verbose := false // Start with verbose mode off by default
 	if len(os.Args) > 1 && os.Args[1] == "-v" {
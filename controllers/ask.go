@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"prabandh/llm"
+	"prabandh/llm/generation"
+	"prabandh/pkg/redact"
+	"prabandh/rag"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AskController struct {
+	assistant *rag.Assistant
+}
+
+func NewAskController(db *gorm.DB, provider llm.Provider, redactMode redact.Mode) *AskController {
+	return &AskController{
+		assistant: rag.NewAssistant(db, provider, redactMode),
+	}
+}
+
+// answerStats reports generation counters in a JSON-friendly form.
+func answerStats(stats *generation.Stats) gin.H {
+	if stats == nil {
+		return nil
+	}
+	return gin.H{
+		"prompt_eval_count": stats.PromptEvalCount,
+		"eval_count":        stats.EvalCount,
+		"eval_duration_ms":  stats.EvalDuration.Milliseconds(),
+		"total_duration_ms": stats.TotalDuration.Milliseconds(),
+		"tokens_per_second": stats.TokensPerSecond(),
+	}
+}
+
+// Ask answers a question from the content of indexed files. By default the
+// answer is streamed as server-sent events: a "sources" event listing the
+// files used, a "token" event per chunk of the answer and a final "done" (or
+// "error") event. With "stream": false the full answer is returned as JSON.
+func (ac *AskController) Ask(c *gin.Context) {
+	var input struct {
+		Question string `json:"question" binding:"required"`
+		Limit    int    `json:"limit"`
+		Stream   *bool  `json:"stream"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	question := strings.TrimSpace(input.Question)
+	if question == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "question is required"})
+		return
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = rag.DefaultSources
+	}
+	if limit > rag.MaxSources {
+		limit = rag.MaxSources
+	}
+
+	retrieval, err := ac.assistant.Retrieve(c.Request.Context(), question, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Retrieval failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if input.Stream != nil && !*input.Stream {
+		var answer strings.Builder
+		stats, err := ac.assistant.Answer(c.Request.Context(), retrieval, func(token string) error {
+			answer.WriteString(token)
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":   "Answer generation failed",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"question": question,
+			"answer":   answer.String(),
+			"sources":  retrieval.Sources,
+			"stats":    answerStats(stats),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.SSEvent("sources", retrieval.Sources)
+	c.Writer.Flush()
+
+	// Generation stops when the client goes away and the request context is cancelled
+	stats, err := ac.assistant.Answer(c.Request.Context(), retrieval, func(token string) error {
+		c.SSEvent("token", gin.H{"text": token})
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": err.Error()})
+	} else {
+		c.SSEvent("done", gin.H{
+			"sources": retrieval.Sources,
+			"stats":   answerStats(stats),
+		})
+	}
+	c.Writer.Flush()
+}
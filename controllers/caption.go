@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"prabandh/database"
+	"prabandh/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetFileCaption returns the vision model's description of an image file.
+func GetFileCaption(c *gin.Context) {
+	db := database.DB.WithContext(c.Request.Context())
+
+	var file models.FileIndex
+	if err := db.First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	var caption models.FileCaption
+	err := db.Where("hash = ?", file.Hash).First(&caption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File has no caption"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"file_id": file.ID,
+		"caption": caption,
+	})
+}
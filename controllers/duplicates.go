@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"prabandh/database"
+	"prabandh/duplicates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDuplicates reports duplicate files grouped by hash, or near-duplicate text
+// files grouped by simhash when mode=near.
+func GetDuplicates(c *gin.Context) {
+	finder := duplicates.NewFinder(database.DB.WithContext(c.Request.Context()))
+
+	var report *duplicates.Report
+	var err error
+	switch mode := c.DefaultQuery("mode", "exact"); mode {
+	case "exact":
+		report, err = finder.Exact()
+	case "near":
+		distance := duplicates.DefaultMaxDistance
+		if d := c.Query("distance"); d != "" {
+			if distance, err = strconv.Atoi(d); err != nil || distance < 0 || distance > duplicates.MaxDistance {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "distance must be between 0 and " + strconv.Itoa(duplicates.MaxDistance)})
+				return
+			}
+		}
+		report, err = finder.Near(distance)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be exact or near"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"prabandh/database"
+	"prabandh/models"
+	"prabandh/pkg/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFileEntities lists the named entities found in a file, optionally only
+// those of one type (person, org, place, date or amount).
+func GetFileEntities(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file id"})
+		return
+	}
+
+	tx := database.DB.WithContext(c.Request.Context()).Where("file_index_id = ?", id)
+	if name := c.Query("type"); name != "" {
+		t, err := entities.ParseType(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tx = tx.Where("type = ?", string(t))
+	}
+
+	var found []models.Entity
+	if err := tx.Order("type, value").Find(&found).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"file_id":  id,
+		"entities": found,
+	})
+}
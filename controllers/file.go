@@ -22,15 +22,3 @@ func AddFile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "File added successfully", "file": file})
 }
-
-func SearchFiles(c *gin.Context) {
-	query := c.Query("query")
-	var files []models.FileIndex
-
-	if err := database.DB.Where("file_path LIKE ?", "%"+query+"%").Find(&files).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, files)
-}
@@ -32,3 +32,28 @@ func GetIndexDirs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, indexDirs)
 }
+
+// SetIndexDirCaptions turns image captioning by the vision model on or off
+// for files indexed from then on under an index directory.
+func SetIndexDirCaptions(c *gin.Context) {
+	var input struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var indexDir models.IndexDir
+	if err := database.DB.First(&indexDir, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Index directory not found"})
+		return
+	}
+
+	if err := database.DB.Model(&indexDir).Update("caption_images", *input.Enabled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Index directory updated successfully", "index_dir": indexDir})
+}
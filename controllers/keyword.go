@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prabandh/keywords"
+	"prabandh/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type KeywordController struct {
+	db    *gorm.DB
+	store *keywords.Store
+}
+
+func NewKeywordController(db *gorm.DB) *KeywordController {
+	return &KeywordController{
+		db:    db,
+		store: keywords.NewStore(db),
+	}
+}
+
+func (kc *KeywordController) CompleteKeywords(c *gin.Context) {
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix query parameter is required"})
+		return
+	}
+
+	completions, err := kc.store.Complete(prefix, searchLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prefix":   prefix,
+		"keywords": completions,
+	})
+}
+
+func (kc *KeywordController) TopKeywords(c *gin.Context) {
+	var indexDir *models.IndexDir
+	if id := c.Query("index_dir_id"); id != "" {
+		dirID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid index_dir_id"})
+			return
+		}
+
+		indexDir = &models.IndexDir{}
+		if err := kc.db.First(indexDir, dirID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Index directory not found"})
+			return
+		}
+	}
+
+	top, err := kc.store.Top(searchLimit(c), indexDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"keywords": top})
+}
+
+func (kc *KeywordController) RelatedKeywords(c *gin.Context) {
+	keyword := c.Query("keyword")
+	if keyword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keyword query parameter is required"})
+		return
+	}
+
+	related, err := kc.store.Related(keyword, searchLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keyword":  keyword,
+		"keywords": related,
+	})
+}
+
+func (kc *KeywordController) GetSynonyms(c *gin.Context) {
+	synonyms, err := kc.store.Synonyms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, synonyms)
+}
+
+// AddSynonym maps an alias onto a canonical keyword, merging the alias into it
+// if it is already used as a keyword.
+func (kc *KeywordController) AddSynonym(c *gin.Context) {
+	var input struct {
+		Alias     string `json:"alias" binding:"required"`
+		Canonical string `json:"canonical" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	synonym, err := kc.store.AddSynonym(input.Alias, input.Canonical)
+	if errors.Is(err, keywords.ErrEmptyKeyword) || errors.Is(err, keywords.ErrSameKeyword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Synonym added successfully",
+		"synonym": synonym,
+	})
+}
+
+func (kc *KeywordController) DeleteSynonym(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid synonym id"})
+		return
+	}
+
+	if err := kc.store.DeleteSynonym(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Synonym not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Synonym deleted successfully"})
+}
+
+// RebuildKeywords re-derives canonical keyword links from every stored FileSummary row.
+func (kc *KeywordController) RebuildKeywords(c *gin.Context) {
+	files, err := kc.store.Rebuild()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Keywords rebuilt successfully",
+		"files":   files,
+	})
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+
+	"prabandh/llm"
+	"prabandh/llm/ollama"
+	"prabandh/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LLMController struct {
+	db       *gorm.DB
+	provider llm.Provider
+}
+
+func NewLLMController(db *gorm.DB, provider llm.Provider) *LLMController {
+	return &LLMController{
+		db:       db,
+		provider: provider,
+	}
+}
+
+// Health reports the LLM model, its circuit breaker and rate limiter state
+// and how many files are queued for a retry.
+func (lc *LLMController) Health(c *gin.Context) {
+	var pending int64
+	if err := lc.db.WithContext(c.Request.Context()).Model(&models.PendingFile{}).Count(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"model":         lc.provider.ModelName(),
+		"pending_files": pending,
+	}
+	// Only Ollama calls go through a breaker and rate limiter
+	if client, ok := lc.provider.(*ollama.Client); ok {
+		health := client.Health()
+		response["fallbacks"] = client.Fallbacks
+		response["breaker"] = health.Breaker
+		response["rate_per_second"] = health.Rate
+	}
+	c.JSON(http.StatusOK, response)
+}
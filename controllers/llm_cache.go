@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	"prabandh/llm/cache"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LLMCacheController struct {
+	cache *cache.Cache
+}
+
+func NewLLMCacheController(db *gorm.DB) *LLMCacheController {
+	return &LLMCacheController{
+		cache: cache.New(db),
+	}
+}
+
+// GetLLMCacheStats reports keyword cache entries and hit rates.
+func (lc *LLMCacheController) GetLLMCacheStats(c *gin.Context) {
+	stats, err := lc.cache.WithContext(c.Request.Context()).Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// InvalidateLLMCache deletes cached keywords matching the model,
+// prompt_version and hash query parameters. With no parameters every entry
+// is removed.
+func (lc *LLMCacheController) InvalidateLLMCache(c *gin.Context) {
+	var filter cache.Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deleted, err := lc.cache.WithContext(c.Request.Context()).Invalidate(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
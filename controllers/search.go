@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prabandh/llm"
+	"prabandh/search"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchController struct {
+	searcher *search.Searcher
+}
+
+func NewSearchController(db *gorm.DB, provider llm.Provider) *SearchController {
+	return &SearchController{
+		searcher: search.NewSearcher(db, provider),
+	}
+}
+
+func (sc *SearchController) HybridSearch(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
+		return
+	}
+
+	parsed, err := search.ParseQuery(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid search query",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	page, err := sc.searcher.Search(c.Request.Context(), parsed, searchOptions(c))
+	if errors.Is(err, search.ErrInvalidOptions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Search failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":       query,
+		"signals":     page.Signals,
+		"results":     page.Results,
+		"next_cursor": page.NextCursor,
+		"facets":      page.Facets,
+		"suggestions": page.Suggestions,
+	})
+}
+
+// SearchFiles matches files whose path contains the query parameter, with the
+// cursor pagination, sort orders and facets of HybridSearch.
+func (sc *SearchController) SearchFiles(c *gin.Context) {
+	query := c.Query("query")
+	parsed, err := search.PathQuery(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid search query",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	page, err := sc.searcher.Search(c.Request.Context(), parsed, searchOptions(c))
+	if errors.Is(err, search.ErrInvalidOptions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Search failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":       query,
+		"results":     page.Results,
+		"next_cursor": page.NextCursor,
+		"facets":      page.Facets,
+	})
+}
+
+func (sc *SearchController) SemanticSearch(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
+		return
+	}
+
+	results, err := sc.searcher.Semantic(c.Request.Context(), query, searchLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Semantic search failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": results,
+	})
+}
+
+func (sc *SearchController) ContentSearch(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
+		return
+	}
+
+	results, err := sc.searcher.Content(c.Request.Context(), query, searchLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Content search failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": results,
+	})
+}
+
+// SimilarFiles ranks files resembling the given file by shared keywords and embeddings.
+func (sc *SearchController) SimilarFiles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file id"})
+		return
+	}
+
+	results, signals, err := sc.searcher.Similar(c.Request.Context(), uint(id), search.SimilarOptions{
+		Limit:         searchLimit(c),
+		SameDir:       c.Query("same_dir") == "true",
+		SameExtension: c.Query("same_ext") == "true",
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"file_id": id,
+		"signals": signals,
+		"results": results,
+	})
+}
+
+// searchOptions reads the paging, sort, facet and fuzzy matching parameters
+// shared by the search endpoints.
+func searchOptions(c *gin.Context) search.Options {
+	return search.Options{
+		Limit:  searchLimit(c),
+		Sort:   c.Query("sort"),
+		Order:  c.Query("order"),
+		Cursor: c.Query("cursor"),
+		Facets: c.Query("facets") != "false",
+
+		Fuzzy:     c.Query("fuzzy") == "true",
+		Threshold: similarityThreshold(c),
+	}
+}
+
+// searchLimit reads the optional limit query parameter, clamped to maxSearchLimit.
+func searchLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		return maxSearchLimit
+	}
+	return limit
+}
+
+// similarityThreshold reads the optional threshold query parameter; zero selects the default.
+func similarityThreshold(c *gin.Context) float64 {
+	threshold, err := strconv.ParseFloat(c.Query("threshold"), 64)
+	if err != nil {
+		return 0
+	}
+	return threshold
+}
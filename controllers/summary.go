@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net/http"
-	"strings"
-	"time"
+	"strconv"
 
+	"prabandh/indexer"
+	"prabandh/keywords"
 	"prabandh/llm/ollama"
 	"prabandh/models"
 
@@ -14,13 +18,20 @@ import (
 
 type SummaryController struct {
 	db           *gorm.DB
-	ollamaClient *ollama.Client
+	fileIndexer  *indexer.FileIndexer
+	keywordStore *keywords.Store
 }
 
-func NewSummaryController(db *gorm.DB, ollamaURL string) *SummaryController {
+const (
+	defaultRegenerateLimit = 100
+	maxRegenerateLimit     = 1000
+)
+
+func NewSummaryController(db *gorm.DB, fileIndexer *indexer.FileIndexer) *SummaryController {
 	return &SummaryController{
 		db:           db,
-		ollamaClient: ollama.New(ollamaURL, "gemma:2b"),
+		fileIndexer:  fileIndexer,
+		keywordStore: keywords.NewStore(db),
 	}
 }
 
@@ -35,7 +46,27 @@ func (sc *SummaryController) AddFileSummary(c *gin.Context) {
 		return
 	}
 
-	keywords, err := sc.generateKeywords(input.Content)
+	var file models.FileIndex
+	if err := sc.db.WithContext(c.Request.Context()).First(&file, input.FileIndexID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	// The content is cached by its own hash, as it may differ from the file's
+	sum := sha256.Sum256([]byte(input.Content))
+	result, err := sc.fileIndexer.Tag(c.Request.Context(), file, hex.EncodeToString(sum[:]), input.Content)
+	if errors.Is(err, indexer.ErrSensitive) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ollama.ErrUnavailable) {
+		c.Header("Retry-After", strconv.Itoa(int(ollama.BreakerCooldown.Seconds())))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Keyword generation is temporarily unavailable",
+			"details": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Keyword generation failed",
@@ -44,19 +75,35 @@ func (sc *SummaryController) AddFileSummary(c *gin.Context) {
 		return
 	}
 
-	summary := models.FileSummary{
-		FileIndexID:    input.FileIndexID,
-		SummaryKeyword: strings.Join(keywords, ","),
+	keywords := result.Keywords
+	if len(keywords) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Keyword generation returned no usable keywords"})
+		return
+	}
+
+	// Store one row per keyword, as the indexer does
+	summaries := make([]models.FileSummary, len(keywords))
+	for i, keyword := range keywords {
+		summaries[i] = models.FileSummary{
+			FileIndexID:    input.FileIndexID,
+			SummaryKeyword: keyword,
+			PromptVersion:  result.PromptVersion,
+		}
+	}
+
+	if err := sc.db.WithContext(c.Request.Context()).Create(&summaries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := sc.db.Create(&summary).Error; err != nil {
+	if err := sc.keywordStore.WithContext(c.Request.Context()).Attach(input.FileIndexID, keywords); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Summary created successfully",
-		"summary": summary,
+		"message":   "Summary created successfully",
+		"summaries": summaries,
 	})
 }
 
@@ -90,37 +137,38 @@ func (sc *SummaryController) GetFileSummaries(c *gin.Context) {
 	})
 }
 
-func (sc *SummaryController) generateKeywords(text string) ([]string, error) {
-	const maxRetries = 3
-	var keywords []string
-	var err error
-
-	prompt := `Extract 5-7 most relevant keywords from this text.
-Return ONLY lowercase, comma-separated terms.
-Example: "ai,healthcare,data analysis"
-
-Text: ` + text
-
-	for i := 0; i < maxRetries; i++ {
-		keywords, err = sc.ollamaClient.ExtractKeywords(prompt)
-		if err == nil {
-			break
+// RegenerateKeywords re-runs keyword generation for files tagged by an older
+// prompt version than the template that now applies to them. The optional
+// version query parameter restricts it to files tagged by that version; limit
+// bounds how many files are processed, and "more" in the response says whether
+// stale files remain.
+func (sc *SummaryController) RegenerateKeywords(c *gin.Context) {
+	limit := defaultRegenerateLimit
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
 		}
-		time.Sleep(time.Second * time.Duration(i+1))
+		limit = min(parsed, maxRegenerateLimit)
 	}
 
-	if err != nil {
-		return nil, err
+	result, err := sc.fileIndexer.Regenerate(c.Request.Context(), c.Query("version"), limit)
+	if errors.Is(err, ollama.ErrUnavailable) {
+		c.Header("Retry-After", strconv.Itoa(int(ollama.BreakerCooldown.Seconds())))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":  "Keyword generation is temporarily unavailable",
+			"result": result,
+		})
+		return
 	}
-
-	cleaned := make([]string, 0, len(keywords))
-	for _, kw := range keywords {
-		kw = strings.ToLower(strings.TrimSpace(kw))
-		kw = strings.Trim(kw, `.,;:"'!?"`)
-		if len(kw) > 2 && len(kw) < 50 {
-			cleaned = append(cleaned, kw)
-		}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  err.Error(),
+			"result": result,
+		})
+		return
 	}
 
-	return cleaned, nil
+	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prabandh/database"
+	"prabandh/taxonomy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCategories returns the taxonomy as a tree with file counts per category.
+func GetCategories(c *gin.Context) {
+	tree, err := taxonomy.NewStore(database.DB.WithContext(c.Request.Context())).Tree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// AddCategory defines a category, or updates the description of an existing one.
+func AddCategory(c *gin.Context) {
+	var input struct {
+		Path        string `json:"path" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	category, err := taxonomy.NewStore(database.DB.WithContext(c.Request.Context())).Add(input.Path, input.Description)
+	if errors.Is(err, taxonomy.ErrInvalidPath) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category saved", "category": category})
+}
+
+// DeleteCategory removes a category and unassigns its files.
+func DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category id"})
+		return
+	}
+
+	err = taxonomy.NewStore(database.DB.WithContext(c.Request.Context())).Delete(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+}
+
+// GetCategoryFiles lists files in the category given by the path query
+// parameter, including its subcategories.
+func GetCategoryFiles(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
+
+	path := c.Query("path")
+	files, err := taxonomy.NewStore(database.DB.WithContext(c.Request.Context())).Files(path, searchLimit(c), offset)
+	if errors.Is(err, taxonomy.ErrInvalidPath) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path query parameter is required"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"path":  path,
+		"files": files,
+	})
+}
+
+// GetFileCategory returns the category assigned to a file.
+func GetFileCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file id"})
+		return
+	}
+
+	assignment, err := taxonomy.NewStore(database.DB.WithContext(c.Request.Context())).Assignment(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if assignment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File has no category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// SetFileCategory corrects a file's category by hand. The classifier never
+// overwrites a manual assignment.
+func SetFileCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file id"})
+		return
+	}
+
+	var input struct {
+		Category string `json:"category" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	assignment, err := taxonomy.NewStore(database.DB.WithContext(c.Request.Context())).Correct(uint(id), input.Category)
+	switch {
+	case errors.Is(err, taxonomy.ErrInvalidPath), errors.Is(err, taxonomy.ErrUnknownCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category updated", "assignment": assignment})
+}
@@ -1,11 +1,13 @@
 package database
 
 import (
-	// "fmt"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"prabandh/models"
+	"prabandh/pkg/langdetect"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -31,15 +33,98 @@ func Connect() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = DB.AutoMigrate(&models.FileIndex{}, &models.FileSummary{}, &models.IndexDir{})
+	// pgvector must be available before migrating the embedding column
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+		log.Fatalf("Failed to enable pgvector extension: %v", err)
+	}
+
+	// pg_trgm provides trigram similarity for file name ranking and fuzzy search
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Fatalf("Failed to enable pg_trgm extension: %v", err)
+	}
+
+	err = DB.AutoMigrate(&models.FileIndex{}, &models.FileSummary{}, &models.IndexDir{}, &models.FileEmbedding{}, &models.FileContent{},
+		&models.Keyword{}, &models.FileKeyword{}, &models.KeywordSynonym{}, &models.KeywordCache{}, &models.PendingFile{},
+		&models.Category{}, &models.FileCategory{}, &models.Entity{}, &models.FileCaption{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	// Create index for keyword search
-	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_summary_keyword_search ON file_summaries USING gin(to_tsvector('english', summary_keyword))").Error; err != nil {
+	// Create trigram indexes for typo-tolerant file name and keyword matching
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_file_name_trgm ON file_indices USING gin(file_name gin_trgm_ops)").Error; err != nil {
+		log.Printf("Warning: Could not create file name trigram index: %v", err)
+	}
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_summary_keyword_trgm ON file_summaries USING gin(summary_keyword gin_trgm_ops)").Error; err != nil {
+		log.Printf("Warning: Could not create keyword trigram index: %v", err)
+	}
+
+	// Add a generated tsvector column and index for full-text search over
+	// extracted content, built with each row's language configuration. Older
+	// databases built it with 'english' for every row, so it is rebuilt once.
+	var tsvExpression string
+	DB.Raw("SELECT generation_expression FROM information_schema.columns WHERE table_name = 'file_contents' AND column_name = 'content_tsv'").Scan(&tsvExpression)
+	if tsvExpression != "" && !strings.Contains(tsvExpression, "search_config") {
+		if err := DB.Exec("ALTER TABLE file_contents DROP COLUMN content_tsv").Error; err != nil {
+			log.Printf("Warning: Could not drop old content search column: %v", err)
+		}
+	}
+	if err := DB.Exec("ALTER TABLE file_contents ADD COLUMN IF NOT EXISTS content_tsv tsvector GENERATED ALWAYS AS (to_tsvector(search_config, content)) STORED").Error; err != nil {
+		log.Printf("Warning: Could not add content search column: %v", err)
+	}
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_file_contents_tsv ON file_contents USING gin(content_tsv)").Error; err != nil {
+		log.Printf("Warning: Could not create content search index: %v", err)
+	}
+
+	// Content is indexed with one of several configurations, so queries are
+	// parsed with each of them and combined
+	for name, parse := range map[string]string{
+		"multilingual_websearch_query": "websearch_to_tsquery",
+		"multilingual_phrase_query":    "phraseto_tsquery",
+	} {
+		if err := DB.Exec(multilingualQueryFunction(name, parse)).Error; err != nil {
+			log.Printf("Warning: Could not create %s: %v", name, err)
+		}
+	}
+
+	// Keywords may be in any language, or translated, so they are indexed
+	// under every configuration for keyword search
+	if err := DB.Exec(multilingualVectorFunction()).Error; err != nil {
+		log.Printf("Warning: Could not create multilingual_tsvector: %v", err)
+	}
+	if err := DB.Exec("DROP INDEX IF EXISTS idx_summary_keyword_search").Error; err != nil {
+		log.Printf("Warning: Could not drop the English keyword index: %v", err)
+	}
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_summary_keyword_multilingual ON file_summaries USING gin(multilingual_tsvector(summary_keyword))").Error; err != nil {
 		log.Printf("Warning: Could not create full-text search index: %v", err)
 	}
 
+	// Create HNSW index for nearest-neighbour search over embeddings
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_file_embeddings_hnsw ON file_embeddings USING hnsw (embedding vector_cosine_ops)").Error; err != nil {
+		log.Printf("Warning: Could not create embedding index: %v", err)
+	}
+
 	// fmt.Println("Database connection established and migrated")
 }
+
+// multilingualQueryFunction defines an SQL function name(text) that ORs the
+// query parsed by parse under every configuration content can be indexed with.
+func multilingualQueryFunction(name, parse string) string {
+	parts := make([]string, 0, len(langdetect.SearchConfigs()))
+	for _, config := range langdetect.SearchConfigs() {
+		parts = append(parts, fmt.Sprintf("%s('%s', query)", parse, config))
+	}
+	return fmt.Sprintf("CREATE OR REPLACE FUNCTION %s(query text) RETURNS tsquery LANGUAGE sql IMMUTABLE AS $$ SELECT %s $$",
+		name, strings.Join(parts, " || "))
+}
+
+// multilingualVectorFunction defines multilingual_tsvector(text), the
+// document parsed under every configuration content can be indexed with, to
+// be matched by the multilingual_* queries.
+func multilingualVectorFunction() string {
+	parts := make([]string, 0, len(langdetect.SearchConfigs()))
+	for _, config := range langdetect.SearchConfigs() {
+		parts = append(parts, fmt.Sprintf("to_tsvector('%s', document)", config))
+	}
+	return fmt.Sprintf("CREATE OR REPLACE FUNCTION multilingual_tsvector(document text) RETURNS tsvector LANGUAGE sql IMMUTABLE AS $$ SELECT %s $$",
+		strings.Join(parts, " || "))
+}
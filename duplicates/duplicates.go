@@ -0,0 +1,125 @@
+package duplicates
+
+import (
+	"path/filepath"
+	"sort"
+
+	"prabandh/models"
+
+	"gorm.io/gorm"
+)
+
+// Finder reports files whose content is duplicated across the index.
+type Finder struct {
+	db *gorm.DB
+}
+
+func NewFinder(db *gorm.DB) *Finder {
+	return &Finder{db: db}
+}
+
+// Group is a set of files with identical (or, in near mode, nearly identical)
+// content. The first file is treated as the original; every other copy counts
+// towards WastedBytes.
+type Group struct {
+	Hash        string             `json:"hash,omitempty"`     // Shared hash of exact duplicates
+	Distance    int                `json:"distance,omitempty"` // Largest simhash distance within a near-duplicate group
+	Size        int64              `json:"size"`
+	WastedBytes int64              `json:"wasted_bytes"`
+	Files       []models.FileIndex `json:"files"`
+}
+
+// DirectoryWaste totals the redundant copies found in one directory.
+type DirectoryWaste struct {
+	Directory   string `json:"directory"`
+	Copies      int    `json:"copies"`
+	WastedBytes int64  `json:"wasted_bytes"`
+}
+
+// Report lists duplicate groups, largest waste first, with per-directory totals.
+type Report struct {
+	Groups           []Group          `json:"groups"`
+	Directories      []DirectoryWaste `json:"directories"`
+	TotalWastedBytes int64            `json:"total_wasted_bytes"`
+}
+
+// Exact groups non-empty files sharing the same SHA-256 hash.
+func (f *Finder) Exact() (*Report, error) {
+	var hashes []string
+	err := f.db.Model(&models.FileIndex{}).
+		Where("size > 0 AND hash NOT IN ?", []string{"", "error-hash"}).
+		Group("hash").
+		Having("COUNT(*) > 1").
+		Pluck("hash", &hashes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Groups: []Group{}, Directories: []DirectoryWaste{}}
+	if len(hashes) == 0 {
+		return report, nil
+	}
+
+	// Oldest copy first so it is treated as the original
+	var files []models.FileIndex
+	err = f.db.Where("hash IN ?", hashes).
+		Order("hash, created_date, id").
+		Find(&files).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[string][]models.FileIndex)
+	for _, file := range files {
+		byHash[file.Hash] = append(byHash[file.Hash], file)
+	}
+
+	for _, hash := range hashes {
+		group := byHash[hash]
+		if len(group) < 2 {
+			continue
+		}
+		size := group[0].Size
+		report.Groups = append(report.Groups, Group{
+			Hash:        hash,
+			Size:        size,
+			WastedBytes: size * int64(len(group)-1),
+			Files:       group,
+		})
+	}
+
+	report.finish()
+	return report, nil
+}
+
+// finish sorts groups by waste and totals waste per directory.
+func (r *Report) finish() {
+	sort.SliceStable(r.Groups, func(i, j int) bool {
+		return r.Groups[i].WastedBytes > r.Groups[j].WastedBytes
+	})
+
+	byDir := make(map[string]*DirectoryWaste)
+	for _, group := range r.Groups {
+		r.TotalWastedBytes += group.WastedBytes
+		for _, file := range group.Files[1:] {
+			dir := filepath.Dir(file.FilePath)
+			dw, ok := byDir[dir]
+			if !ok {
+				dw = &DirectoryWaste{Directory: dir}
+				byDir[dir] = dw
+			}
+			dw.Copies++
+			dw.WastedBytes += file.Size
+		}
+	}
+
+	for _, dw := range byDir {
+		r.Directories = append(r.Directories, *dw)
+	}
+	sort.Slice(r.Directories, func(i, j int) bool {
+		if r.Directories[i].WastedBytes != r.Directories[j].WastedBytes {
+			return r.Directories[i].WastedBytes > r.Directories[j].WastedBytes
+		}
+		return r.Directories[i].Directory < r.Directories[j].Directory
+	})
+}
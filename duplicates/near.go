@@ -0,0 +1,159 @@
+package duplicates
+
+import (
+	"sort"
+
+	"prabandh/models"
+	"prabandh/pkg/simhash"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultMaxDistance is the largest simhash distance treated as a near duplicate.
+	DefaultMaxDistance = 3
+	// MaxDistance bounds the distance accepted by Near; larger values match unrelated text.
+	MaxDistance = 10
+)
+
+type fingerprint struct {
+	Hash    string
+	Simhash int64
+}
+
+// Near groups text files whose extracted content has simhash fingerprints at
+// most maxDistance bits apart. Files with byte-identical content also group
+// together; use Exact to report only those.
+func (f *Finder) Near(maxDistance int) (*Report, error) {
+	if maxDistance < 0 || maxDistance > MaxDistance {
+		maxDistance = DefaultMaxDistance
+	}
+
+	if err := f.backfillFingerprints(); err != nil {
+		return nil, err
+	}
+
+	var prints []fingerprint
+	err := f.db.Raw(`
+		SELECT c.hash, c.simhash
+		FROM file_contents c
+		WHERE c.deleted_at IS NULL AND c.simhash IS NOT NULL AND c.simhash <> 0
+			AND EXISTS (SELECT 1 FROM file_indices f WHERE f.hash = c.hash AND f.deleted_at IS NULL)`).
+		Scan(&prints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := cluster(prints, maxDistance)
+
+	report := &Report{Groups: []Group{}, Directories: []DirectoryWaste{}}
+	for _, members := range clusters {
+		hashes := make([]string, len(members))
+		for i, m := range members {
+			hashes[i] = m.Hash
+		}
+
+		var files []models.FileIndex
+		if err := f.db.Where("hash IN ?", hashes).Order("created_date, id").Find(&files).Error; err != nil {
+			return nil, err
+		}
+		if len(files) < 2 {
+			continue
+		}
+
+		group := Group{Size: files[0].Size, Files: files}
+		for _, file := range files[1:] {
+			group.WastedBytes += file.Size
+		}
+		for i := range members {
+			for j := i + 1; j < len(members); j++ {
+				if d := simhash.Distance(uint64(members[i].Simhash), uint64(members[j].Simhash)); d > group.Distance {
+					group.Distance = d
+				}
+			}
+		}
+		report.Groups = append(report.Groups, group)
+	}
+
+	report.finish()
+	return report, nil
+}
+
+// backfillFingerprints computes simhashes for content stored before fingerprints existed.
+func (f *Finder) backfillFingerprints() error {
+	var pending []models.FileContent
+	return f.db.Where("simhash IS NULL").FindInBatches(&pending, 100, func(tx *gorm.DB, batch int) error {
+		for _, content := range pending {
+			fp := int64(simhash.Simhash(content.Content))
+			if err := f.db.Model(&models.FileContent{}).Where("id = ?", content.ID).Update("simhash", fp).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// cluster links fingerprints within maxDistance of each other and returns the
+// connected groups with more than one member. Candidate pairs come from
+// splitting fingerprints into maxDistance+1 bands: by the pigeonhole principle
+// two fingerprints within maxDistance bits agree exactly on at least one band.
+func cluster(prints []fingerprint, maxDistance int) [][]fingerprint {
+	parent := make([]int, len(prints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	bands := maxDistance + 1
+	width := 64 / bands
+	for band := 0; band < bands; band++ {
+		shift := uint(band * width)
+		bits := width
+		if band == bands-1 {
+			bits = 64 - band*width
+		}
+		mask := uint64(1)<<uint(bits) - 1
+
+		buckets := make(map[uint64][]int)
+		for i, p := range prints {
+			key := (uint64(p.Simhash) >> shift) & mask
+			buckets[key] = append(buckets[key], i)
+		}
+
+		for _, bucket := range buckets {
+			for i := 0; i < len(bucket); i++ {
+				for j := i + 1; j < len(bucket); j++ {
+					a, b := bucket[i], bucket[j]
+					if find(a) == find(b) {
+						continue
+					}
+					if simhash.Distance(uint64(prints[a].Simhash), uint64(prints[b].Simhash)) <= maxDistance {
+						parent[find(a)] = find(b)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]fingerprint)
+	for i, p := range prints {
+		root := find(i)
+		groups[root] = append(groups[root], p)
+	}
+
+	var clusters [][]fingerprint
+	for _, members := range groups {
+		if len(members) > 1 {
+			sort.Slice(members, func(i, j int) bool { return members[i].Hash < members[j].Hash })
+			clusters = append(clusters, members)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0].Hash < clusters[j][0].Hash })
+	return clusters
+}
@@ -0,0 +1,45 @@
+package duplicates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster(t *testing.T) {
+	prints := []fingerprint{
+		{Hash: "a", Simhash: 0x0F0F0F0F0F0F0F0F},
+		{Hash: "b", Simhash: 0x0F0F0F0F0F0F0F0E}, // 1 bit from a
+		{Hash: "c", Simhash: 0x0F0F0F0F0F0F0F08}, // 2 bits from b, 3 from a
+		{Hash: "d", Simhash: 0x7070707070707070}, // unrelated
+		{Hash: "e", Simhash: 0x7070707070707071}, // 1 bit from d
+		{Hash: "f", Simhash: 0x1234567812345678}, // alone
+	}
+
+	clusters := cluster(prints, 3)
+
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, []string{"a", "b", "c"}, hashesOf(clusters[0]))
+	assert.Equal(t, []string{"d", "e"}, hashesOf(clusters[1]))
+}
+
+func TestCluster_ExactOnly(t *testing.T) {
+	prints := []fingerprint{
+		{Hash: "a", Simhash: 42},
+		{Hash: "b", Simhash: 42},
+		{Hash: "c", Simhash: 43},
+	}
+
+	clusters := cluster(prints, 0)
+
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, []string{"a", "b"}, hashesOf(clusters[0]))
+}
+
+func hashesOf(members []fingerprint) []string {
+	hashes := make([]string, len(members))
+	for i, m := range members {
+		hashes[i] = m.Hash
+	}
+	return hashes
+}
@@ -0,0 +1,98 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"prabandh/database"
+	"prabandh/llm/prompt"
+	"prabandh/models"
+	"prabandh/pkg/redact"
+	"prabandh/pkg/thumbnail"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// captionImageSide is the longest side, in pixels, of images sent to the
+// vision model; LLaVA models see at most 672x672.
+const captionImageSide = 672
+
+// captions reports whether file is an image that the most specific index
+// directory containing it asks to be described by the vision model.
+func (fi *FileIndexer) captions(ctx context.Context, file models.FileIndex) bool {
+	if !thumbnail.CanDecode(file.FilePath) {
+		return false
+	}
+
+	var dir models.IndexDir
+	err := database.DB.WithContext(ctx).
+		Where("directory_location = ? OR left(?, length(directory_location) + 1) = directory_location || '/'", file.FilePath, file.FilePath).
+		Order("length(directory_location) DESC").
+		First(&dir).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) && fi.verbose {
+			fmt.Printf("Failed to look up the index directory of %s: %v\n", file.FilePath, err)
+		}
+		return false
+	}
+	return dir.CaptionImages
+}
+
+// caption describes an image with the vision model and stores the caption
+// under the file's hash. Only a downscaled copy of the image is sent, with
+// the file's name and path masked as the redaction mode requires.
+func (fi *FileIndexer) caption(ctx context.Context, file models.FileIndex) (string, error) {
+	outgoing := file
+	if fi.redactMode != redact.ModeOff {
+		var sensitive bool
+		outgoing, sensitive = maskPath(file)
+		if sensitive && fi.redactMode == redact.ModeRefuse {
+			return "", fmt.Errorf("not describing %s: its path is sensitive", file.FilePath)
+		}
+	}
+
+	tmpl, ok := fi.prompts.Get(prompt.Caption)
+	if !ok {
+		return "", fmt.Errorf("no %q prompt template", prompt.Caption)
+	}
+	rendered, err := tmpl.Render(prompt.NewData(outgoing, ""))
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(file.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	image, err := thumbnail.Downscale(f, captionImageSide)
+	if err != nil {
+		return "", err
+	}
+
+	caption, err := fi.provider.DescribeImage(ctx, rendered, image)
+	if err != nil {
+		return "", err
+	}
+
+	if file.Hash != "" && file.Hash != "error-hash" {
+		record := models.FileCaption{
+			Hash:          file.Hash,
+			Caption:       caption,
+			VisionModel:   fi.provider.VisionModelName(),
+			PromptVersion: tmpl.ID(),
+		}
+		err := database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.AssignmentColumns([]string{"caption", "vision_model", "prompt_version", "updated_at"}),
+		}).Create(&record).Error
+		if err != nil && fi.verbose {
+			fmt.Printf("Failed to save caption for %s: %v\n", file.FilePath, err)
+		}
+	}
+	return caption, nil
+}
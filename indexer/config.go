@@ -0,0 +1,59 @@
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"prabandh/llm/tagger"
+	"prabandh/pkg/ocr"
+	"prabandh/pkg/redact"
+)
+
+// Config configures a FileIndexer.
+type Config struct {
+	Keywords tagger.Config
+	// Redact selects how sensitive data is kept from the model server
+	Redact redact.Mode
+	OCR    ocr.Config
+}
+
+// ConfigFromEnv reads the keyword configuration as tagger.ConfigFromEnv does,
+// the redaction mode from REDACT_MODE and OCR settings from OCR_ENABLED,
+// OCR_LANG and OCR_PAGE_LIMIT.
+func ConfigFromEnv() (Config, error) {
+	keywords, err := tagger.ConfigFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+
+	mode, err := redact.ParseMode(os.Getenv("REDACT_MODE"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	ocrConfig, err := ocrConfigFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{Keywords: keywords, Redact: mode, OCR: ocrConfig}, nil
+}
+
+func ocrConfigFromEnv() (ocr.Config, error) {
+	enabled, _ := strconv.ParseBool(os.Getenv("OCR_ENABLED"))
+	config := ocr.Config{
+		Enabled:   enabled,
+		Languages: strings.TrimSpace(os.Getenv("OCR_LANG")),
+		PageLimit: ocr.DefaultPageLimit,
+	}
+
+	if value := os.Getenv("OCR_PAGE_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return ocr.Config{}, fmt.Errorf("invalid OCR_PAGE_LIMIT %q: must be a positive number", value)
+		}
+		config.PageLimit = limit
+	}
+	return config, nil
+}
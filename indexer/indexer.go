@@ -1,9 +1,11 @@
 package indexer
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,28 +14,73 @@ import (
 	"time"
 
 	"prabandh/database"
-	"prabandh/llm/ollama"
+	"prabandh/keywords"
+	"prabandh/llm"
+	"prabandh/llm/ner"
+	"prabandh/llm/prompt"
+	"prabandh/llm/tagger"
 	"prabandh/models"
+	"prabandh/pkg/langdetect"
+	"prabandh/pkg/ocr"
+	"prabandh/pkg/redact"
+	"prabandh/pkg/simhash"
 	"prabandh/pkg/textractor"
+	"prabandh/taxonomy"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type FileIndexer struct {
 	wg            sync.WaitGroup
 	textExtractor *textractor.TextExtractor
-	ollamaClient  *ollama.Client
+	ocr           *ocr.OCR // nil unless OCR is enabled and tesseract is installed
+	provider      llm.Provider
+	prompts       *prompt.Library
+	tagger        *tagger.Tagger
+	classifier    *taxonomy.Classifier
+	extractor     *ner.Extractor
+	redactMode    redact.Mode
+	slots         chan struct{}
 	verbose       bool
 }
 
-func NewFileIndexer(ollamaURL, model string, verbose bool) *FileIndexer {
-	return &FileIndexer{
+// maxConcurrentFiles bounds how many files are indexed at once.
+const maxConcurrentFiles = 8
+
+func NewFileIndexer(provider llm.Provider, prompts *prompt.Library, config Config, verbose bool) *FileIndexer {
+	fi := &FileIndexer{
 		textExtractor: textractor.NewTextExtractor(),
-		ollamaClient:  ollama.New(ollamaURL, model),
+		provider:      provider,
+		prompts:       prompts,
+		tagger:        tagger.New(database.DB, provider, prompts, config.Keywords),
+		classifier:    taxonomy.NewClassifier(database.DB, provider, prompts),
+		extractor:     ner.New(database.DB, provider, prompts),
+		redactMode:    config.Redact,
+		slots:         make(chan struct{}, maxConcurrentFiles),
 		verbose:       verbose,
 	}
+
+	if config.OCR.Enabled {
+		recogniser, err := ocr.New(config.OCR)
+		if err != nil {
+			log.Printf("OCR is disabled: %v", err)
+		} else {
+			fi.ocr = recogniser
+		}
+	}
+	return fi
 }
 
-func (fi *FileIndexer) IndexDirectory(dirPath string) {
+// IndexDirectory indexes every file under dirPath. It stops walking as soon as
+// ctx is cancelled, waits for files already in progress to abandon their work,
+// and returns ctx's error.
+func (fi *FileIndexer) IndexDirectory(ctx context.Context, dirPath string) error {
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if err != nil {
 			if fi.verbose {
 				fmt.Printf("Error accessing path %s: %v\n", path, err)
@@ -42,22 +89,34 @@ func (fi *FileIndexer) IndexDirectory(dirPath string) {
 		}
 
 		if !info.IsDir() {
+			// Bound concurrency so an LLM outage cannot pile up goroutines
+			select {
+			case fi.slots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
 			fi.wg.Add(1)
-			go fi.indexFile(path, info)
+			go func() {
+				defer func() { <-fi.slots }()
+				fi.indexFile(ctx, path, info)
+			}()
 		}
 		return nil
 	})
 
-	if err != nil && fi.verbose {
-		fmt.Printf("Error walking directory %s: %v\n", dirPath, err)
-	}
-
 	fi.wg.Wait()
+	return err
 }
 
-func (fi *FileIndexer) indexFile(filePath string, info os.FileInfo) {
+func (fi *FileIndexer) indexFile(ctx context.Context, filePath string, info os.FileInfo) {
 	defer fi.wg.Done()
 
+	if ctx.Err() != nil {
+		return
+	}
+	db := database.DB.WithContext(ctx)
+
 	// 1. Collect file metadata
 	creationTime := getCreationTime(info)
 	hash, err := calculateHash(filePath)
@@ -77,7 +136,7 @@ func (fi *FileIndexer) indexFile(filePath string, info os.FileInfo) {
 	}
 
 	// 2. Save file metadata first
-	if err := database.DB.Create(&file).Error; err != nil {
+	if err := db.Create(&file).Error; err != nil {
 		if fi.verbose {
 			fmt.Printf("Failed to save metadata for %s: %v\n", filePath, err)
 		}
@@ -85,12 +144,12 @@ func (fi *FileIndexer) indexFile(filePath string, info os.FileInfo) {
 	}
 
 	// 3. Skip if file type not supported
-	if !fi.textExtractor.CanExtract(filePath) {
+	if !fi.canExtract(ctx, file) {
 		return
 	}
 
-	// 4. Extract text content
-	content, err := fi.textExtractor.ExtractText(filePath)
+	// 4. Extract text content, recognising scans by OCR and describing images
+	content, source, err := fi.extractText(ctx, file)
 	if err != nil {
 		if fi.verbose && !strings.Contains(err.Error(), "unsupported file type") {
 			fmt.Printf("Extraction error for %s: %v\n", filePath, err)
@@ -98,7 +157,33 @@ func (fi *FileIndexer) indexFile(filePath string, info os.FileInfo) {
 		return
 	}
 
-	// 5. Generate keywords from content + metadata
+	// 5. Record the content's language, which selects its full-text configuration
+	fi.detectLanguage(ctx, &file, content)
+
+	// 6. Persist content for full-text search, once per distinct hash
+	fi.saveContent(ctx, file, content, source)
+
+	// 7. Embed and tag the file, queueing it for later if the LLM fails
+	if err := fi.enrich(ctx, file, content); err != nil {
+		if fi.verbose {
+			fmt.Printf("Keyword generation failed for %s, queued for retry: %v\n", filePath, err)
+		}
+		fi.queue(ctx, file, 1, err)
+	}
+}
+
+// enrich stores an embedding, LLM-generated keywords, a taxonomy category and
+// named entities for file, after masking sensitive data in what is sent to the
+// model. Sensitive files refused by the redaction mode only get statistical
+// keywords and pattern-matched entities. It returns an error if keywords could not be generated;
+// everything else is best effort.
+func (fi *FileIndexer) enrich(ctx context.Context, file models.FileIndex, content string) error {
+	file, content, allowed := fi.redact(ctx, file, content)
+	if !allowed {
+		return fi.enrichLocally(ctx, file, content)
+	}
+
+	// Embed the content together with its metadata
 	metadata := fmt.Sprintf(
 		"File: %s\nPath: %s\nSize: %d bytes\nCreated: %s\nModified: %s\nContent:\n%s",
 		file.FileName,
@@ -109,35 +194,196 @@ func (fi *FileIndexer) indexFile(filePath string, info os.FileInfo) {
 		content,
 	)
 
-	keywords, err := fi.ollamaClient.ExtractKeywords(metadata)
+	// Store an embedding for semantic search
+	fi.embedFile(ctx, file, metadata)
+
+	result, err := fi.tagger.Tag(ctx, file, file.Hash, content)
 	if err != nil {
+		return err
+	}
+
+	if err := fi.saveKeywords(ctx, file, result); err != nil {
 		if fi.verbose {
-			fmt.Printf("Keyword generation failed for %s: %v\n", filePath, err)
+			fmt.Printf("Failed to save keywords for %s: %v\n", file.FilePath, err)
+		}
+	} else if fi.verbose {
+		fmt.Printf("Indexed %s with %d keywords\n", file.FilePath, len(result.Keywords))
+	}
+
+	// Assign a taxonomy category; on failure the file stays uncategorised
+	if _, err := fi.classifier.Classify(ctx, file, content); err != nil && fi.verbose {
+		fmt.Printf("Classification failed for %s: %v\n", file.FilePath, err)
+	}
+
+	// Extract named entities; dates and amounts are kept even if the LLM fails
+	if _, err := fi.extractor.Extract(ctx, file, content); err != nil && fi.verbose {
+		fmt.Printf("Entity extraction failed for %s: %v\n", file.FilePath, err)
+	}
+	return nil
+}
+
+// saveKeywords replaces the file's keywords: it stores one summary row per
+// keyword, tagged with the prompt version that produced it, and links the file
+// to canonical keywords.
+func (fi *FileIndexer) saveKeywords(ctx context.Context, file models.FileIndex, result *tagger.Result) error {
+	summaries := make([]models.FileSummary, 0, len(result.Keywords))
+	for _, keyword := range result.Keywords {
+		summaries = append(summaries, models.FileSummary{
+			FileIndexID:    file.ID,
+			SummaryKeyword: keyword,
+			PromptVersion:  result.PromptVersion,
+		})
+	}
+
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("file_index_id = ?", file.ID).Delete(&models.FileSummary{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_index_id = ?", file.ID).Delete(&models.FileKeyword{}).Error; err != nil {
+			return err
 		}
+
+		if len(summaries) > 0 {
+			if err := tx.CreateInBatches(&summaries, 100).Error; err != nil {
+				return err
+			}
+		}
+		return keywords.NewStore(tx).Attach(file.ID, result.Keywords)
+	})
+}
+
+// detectLanguage stores the language of content on file, leaving it empty
+// if it cannot be told.
+func (fi *FileIndexer) detectLanguage(ctx context.Context, file *models.FileIndex, content string) {
+	file.Language = langdetect.Detect(content).Code
+	if file.Language == "" {
 		return
 	}
+	err := database.DB.WithContext(ctx).Model(file).Update("language", file.Language).Error
+	if err != nil && fi.verbose {
+		fmt.Printf("Failed to save language for %s: %v\n", file.FilePath, err)
+	}
+}
+
+// canExtract reports whether text can be read or recognised in file, or,
+// for an image in a directory that asks for captions, described.
+func (fi *FileIndexer) canExtract(ctx context.Context, file models.FileIndex) bool {
+	if fi.textExtractor.CanExtract(file.FilePath) || (fi.ocr != nil && fi.ocr.CanExtract(file.FilePath)) {
+		return true
+	}
+	return fi.captions(ctx, file)
+}
 
-	// 6. Save each keyword as a separate row
-	var summaries []models.FileSummary
-	for _, keyword := range keywords {
-		keyword = strings.TrimSpace(keyword)
-		if keyword != "" {
-			summaries = append(summaries, models.FileSummary{
-				FileIndexID:    file.ID,
-				SummaryKeyword: keyword,
-			})
+// extractText returns the text of file and how it was extracted. Files the
+// text extractor cannot read are recognised by OCR, and images without
+// recognisable text are described by the vision model where their directory
+// asks for it. Both are slow, so content already stored under the file's
+// hash is reused rather than recognising or describing the file again.
+func (fi *FileIndexer) extractText(ctx context.Context, file models.FileIndex) (string, string, error) {
+	if fi.textExtractor.CanExtract(file.FilePath) {
+		content, err := fi.textExtractor.ExtractText(file.FilePath)
+		return content, models.ContentSourceText, err
+	}
+
+	if file.Hash != "" && file.Hash != "error-hash" {
+		var stored models.FileContent
+		err := database.DB.WithContext(ctx).Where("hash = ?", file.Hash).First(&stored).Error
+		if err == nil {
+			return stored.Content, stored.Source, nil
 		}
 	}
 
-	if len(summaries) > 0 {
-		if err := database.DB.CreateInBatches(&summaries, 100).Error; err != nil {
-			if fi.verbose {
-				fmt.Printf("Failed to save keywords for %s: %v\n", filePath, err)
-			}
-		} else if fi.verbose {
-			fmt.Printf("Indexed %s with %d keywords\n", filePath, len(summaries))
+	recognisable := fi.ocr != nil && fi.ocr.CanExtract(file.FilePath)
+	if recognisable {
+		content, err := fi.ocr.ExtractText(ctx, file.FilePath)
+		if err != nil || strings.TrimSpace(content) != "" {
+			return content, models.ContentSourceOCR, err
+		}
+	}
+
+	if fi.captions(ctx, file) {
+		caption, err := fi.caption(ctx, file)
+		return caption, models.ContentSourceCaption, err
+	}
+	if recognisable {
+		return "", models.ContentSourceOCR, nil
+	}
+	return "", "", fmt.Errorf("unsupported file type: %s", filepath.Ext(file.FilePath))
+}
+
+func (fi *FileIndexer) saveContent(ctx context.Context, file models.FileIndex, content, source string) {
+	if file.Hash == "" || file.Hash == "error-hash" {
+		return
+	}
+
+	content = sanitizeContent(content)
+	fingerprint := int64(simhash.Simhash(content))
+	record := models.FileContent{
+		Hash:         file.Hash,
+		Content:      content,
+		Simhash:      &fingerprint,
+		SearchConfig: langdetect.SearchConfig(file.Language),
+		Source:       source,
+	}
+	// Content already stored may have been saved before its language was
+	// detected, so its configuration is brought up to date
+	conflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoNothing: true,
+	}
+	if file.Language != "" {
+		conflict = clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.AssignmentColumns([]string{"search_config"}),
 		}
 	}
+	err := database.DB.WithContext(ctx).Clauses(conflict).Create(&record).Error
+	if err != nil && fi.verbose {
+		fmt.Printf("Failed to save content for %s: %v\n", file.FilePath, err)
+	}
+}
+
+func (fi *FileIndexer) embedFile(ctx context.Context, file models.FileIndex, text string) {
+	embedding, err := fi.provider.Embed(ctx, text)
+	if err != nil {
+		if fi.verbose {
+			fmt.Printf("Embedding failed for %s: %v\n", file.FilePath, err)
+		}
+		return
+	}
+
+	if len(embedding) != models.EmbeddingDimensions {
+		if fi.verbose {
+			fmt.Printf("Skipping embedding for %s: expected %d dimensions, got %d\n", file.FilePath, models.EmbeddingDimensions, len(embedding))
+		}
+		return
+	}
+
+	record := models.FileEmbedding{
+		FileIndexID:    file.ID,
+		EmbeddingModel: fi.provider.EmbedModelName(),
+		Embedding:      models.Vector(embedding),
+	}
+	// A retried file may already have its embedding
+	err = database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_index_id"}},
+		DoNothing: true,
+	}).Create(&record).Error
+	if err != nil && fi.verbose {
+		fmt.Printf("Failed to save embedding for %s: %v\n", file.FilePath, err)
+	}
+}
+
+// maxContentBytes keeps stored content well below Postgres' 1MB tsvector limit.
+const maxContentBytes = 512 * 1024
+
+// sanitizeContent makes extracted text safe to store in a Postgres text column.
+func sanitizeContent(content string) string {
+	if len(content) > maxContentBytes {
+		content = content[:maxContentBytes]
+	}
+	content = strings.ToValidUTF8(content, "")
+	return strings.ReplaceAll(content, "\x00", "")
 }
 
 func calculateHash(filePath string) (string, error) {
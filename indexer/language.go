@@ -0,0 +1,53 @@
+package indexer
+
+import (
+	"context"
+
+	"prabandh/database"
+	"prabandh/models"
+	"prabandh/pkg/langdetect"
+)
+
+// languageBatch is how many stored contents DetectLanguages reads per query.
+const languageBatch = 100
+
+// DetectLanguages detects the language of stored content whose files have
+// none recorded, such as files indexed before languages were detected, and
+// rebuilds the content's full-text vector with the matching configuration.
+// It returns how many files were updated.
+func (fi *FileIndexer) DetectLanguages(ctx context.Context) (int, error) {
+	db := database.DB.WithContext(ctx)
+
+	updated := 0
+	var lastID uint
+	for {
+		var contents []models.FileContent
+		err := db.Where("id > ?", lastID).
+			Where("hash IN (?)", db.Model(&models.FileIndex{}).Select("hash").Where("COALESCE(language, '') = ''")).
+			Order("id").
+			Limit(languageBatch).
+			Find(&contents).Error
+		if err != nil || len(contents) == 0 {
+			return updated, err
+		}
+
+		for _, content := range contents {
+			lastID = content.ID
+			code := langdetect.Detect(content.Content).Code
+			if code == "" {
+				continue
+			}
+
+			files := db.Model(&models.FileIndex{}).Where("hash = ? AND COALESCE(language, '') = ''", content.Hash).Update("language", code)
+			if files.Error != nil {
+				return updated, files.Error
+			}
+			updated += int(files.RowsAffected)
+
+			err := db.Model(&content).Update("search_config", langdetect.SearchConfig(code)).Error
+			if err != nil {
+				return updated, err
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prabandh/database"
+	"prabandh/llm/ollama"
+	"prabandh/models"
+	"prabandh/pkg/resilience"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxPendingAttempts is how many times a file is retried before it is left
+// in the queue for inspection.
+const maxPendingAttempts = 10
+
+// pendingBackoff spaces out retries of a queued file.
+var pendingBackoff = resilience.Backoff{
+	Base: time.Minute,
+	Max:  6 * time.Hour,
+}
+
+// pendingBatch bounds how many queued files one RetryPending call processes.
+const pendingBatch = 100
+
+// queue records that file could not be enriched so RetryPending picks it up
+// later. attempts is the number of failures so far.
+func (fi *FileIndexer) queue(ctx context.Context, file models.FileIndex, attempts int, cause error) {
+	// Queue even when indexing was cancelled, otherwise the file is never retried
+	ctx = context.WithoutCancel(ctx)
+
+	pending := models.PendingFile{
+		FileIndexID:   file.ID,
+		Attempts:      attempts,
+		NextAttemptAt: time.Now().Add(pendingBackoff.Base + pendingBackoff.Delay(attempts)),
+		LastError:     cause.Error(),
+	}
+	err := database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_index_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"attempts", "next_attempt_at", "last_error", "updated_at", "deleted_at"}),
+	}).Create(&pending).Error
+	if err != nil && fi.verbose {
+		fmt.Printf("Failed to queue %s for retry: %v\n", file.FilePath, err)
+	}
+}
+
+// RetryPending retries queued files that are due, returning how many were
+// enriched. It stops early while Ollama is unavailable.
+func (fi *FileIndexer) RetryPending(ctx context.Context) (int, error) {
+	db := database.DB.WithContext(ctx)
+
+	var pending []models.PendingFile
+	err := db.Where("next_attempt_at <= ? AND attempts < ?", time.Now(), maxPendingAttempts).
+		Order("next_attempt_at").
+		Limit(pendingBatch).
+		Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	enriched := 0
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return enriched, err
+		}
+
+		var file models.FileIndex
+		err := db.First(&file, p.FileIndexID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			db.Unscoped().Delete(&p)
+			continue
+		}
+		if err != nil {
+			return enriched, err
+		}
+
+		content, err := fi.pendingContent(ctx, file)
+		if err != nil {
+			// The file is gone or unreadable, so there is nothing to retry
+			if fi.verbose {
+				fmt.Printf("Dropping %s from the retry queue: %v\n", file.FilePath, err)
+			}
+			db.Unscoped().Delete(&p)
+			continue
+		}
+
+		if err := fi.enrich(ctx, file, content); err != nil {
+			fi.queue(ctx, file, p.Attempts+1, err)
+			if errors.Is(err, ollama.ErrUnavailable) {
+				break
+			}
+			continue
+		}
+
+		db.Unscoped().Delete(&p)
+		enriched++
+	}
+	return enriched, nil
+}
+
+// RetryPendingLoop calls RetryPending every interval until ctx is cancelled.
+func (fi *FileIndexer) RetryPendingLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			enriched, err := fi.RetryPending(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) && fi.verbose {
+				fmt.Printf("Retrying queued files failed: %v\n", err)
+			} else if enriched > 0 && fi.verbose {
+				fmt.Printf("Enriched %d queued files\n", enriched)
+			}
+		}
+	}
+}
+
+// pendingContent returns the text of a queued file, preferring the content
+// stored at index time over extracting it again.
+func (fi *FileIndexer) pendingContent(ctx context.Context, file models.FileIndex) (string, error) {
+	var stored models.FileContent
+	err := database.DB.WithContext(ctx).Where("hash = ?", file.Hash).First(&stored).Error
+	if err == nil {
+		return stored.Content, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	content, _, err := fi.extractText(ctx, file)
+	return content, err
+}
@@ -0,0 +1,73 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"prabandh/database"
+	"prabandh/models"
+	"prabandh/pkg/redact"
+)
+
+// redact prepares file and content to be sent to the model server, which may
+// not be local. Unless redaction is off, sensitive data is masked in the
+// content and in the file's name and path, and whether the file is sensitive
+// is recorded in the index. It returns false if the file must not be sent at
+// all.
+func (fi *FileIndexer) redact(ctx context.Context, file models.FileIndex, content string) (models.FileIndex, string, bool) {
+	if fi.redactMode == redact.ModeOff {
+		return file, content, true
+	}
+
+	masked, findings := redact.Mask(content)
+	outgoing, pathSensitive := maskPath(file)
+
+	sensitive := redact.Sensitive(findings) || pathSensitive
+	if sensitive != file.Sensitive {
+		err := database.DB.WithContext(ctx).Model(&file).Update("sensitive", sensitive).Error
+		if err != nil && fi.verbose {
+			fmt.Printf("Failed to flag %s as sensitive: %v\n", file.FilePath, err)
+		}
+	}
+	if fi.verbose && len(findings) > 0 {
+		fmt.Printf("Masked %d sensitive values in %s\n", len(findings), file.FilePath)
+	}
+
+	outgoing.Sensitive = sensitive
+	return outgoing, masked, !fi.refuses(outgoing)
+}
+
+// maskPath masks sensitive data in file's name and path, reporting whether
+// the path alone makes the file sensitive.
+func maskPath(file models.FileIndex) (models.FileIndex, bool) {
+	path, findings := redact.Mask(file.FilePath)
+	file.FilePath = path
+	file.FileName, _ = redact.Mask(file.FileName)
+	return file, redact.Sensitive(findings)
+}
+
+// refuses reports whether nothing from file may be sent to the model server.
+func (fi *FileIndexer) refuses(file models.FileIndex) bool {
+	return fi.redactMode == redact.ModeRefuse && file.Sensitive
+}
+
+// enrichLocally stores keywords for a file that may not be sent to the model
+// server, extracted statistically from its masked content, and the dates and
+// amounts pattern matching finds in it. Entities are best effort, as in enrich.
+func (fi *FileIndexer) enrichLocally(ctx context.Context, file models.FileIndex, content string) error {
+	result, err := fi.tagger.Statistical(ctx, content)
+	if err != nil {
+		return err
+	}
+	if err := fi.saveKeywords(ctx, file, result); err != nil {
+		return err
+	}
+	if fi.verbose {
+		fmt.Printf("Indexed sensitive file %s locally with %d keywords\n", file.FilePath, len(result.Keywords))
+	}
+
+	if _, err := fi.extractor.Patterns(ctx, file, content); err != nil && fi.verbose {
+		fmt.Printf("Entity extraction failed for %s: %v\n", file.FilePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prabandh/database"
+	"prabandh/llm/ollama"
+	"prabandh/models"
+)
+
+// RegenerateResult reports a Regenerate run.
+type RegenerateResult struct {
+	Regenerated int  `json:"regenerated"`
+	Failed      int  `json:"failed"`
+	More        bool `json:"more"` // Stale files remain beyond the limit
+}
+
+// regenerateScan is how many tagged files are examined per query.
+const regenerateScan = 500
+
+// Regenerate re-runs keyword generation for up to limit files whose keywords
+// were produced by a different prompt version than the one that now applies
+// to them, including statistical keywords left by the fallback mode. When
+// version is set, only files tagged by that version are considered. Files
+// that fail are queued for retry; the run stops early while Ollama is
+// unavailable.
+func (fi *FileIndexer) Regenerate(ctx context.Context, version string, limit int) (*RegenerateResult, error) {
+	db := database.DB.WithContext(ctx)
+	result := &RegenerateResult{}
+
+	var lastID uint
+	for {
+		var tagged []struct {
+			FileIndexID uint
+			Versions    int
+			Version     string
+		}
+		query := db.Model(&models.FileSummary{}).
+			Select("file_index_id, COUNT(DISTINCT prompt_version) AS versions, MIN(prompt_version) AS version").
+			Where("file_index_id > ?", lastID)
+		if version != "" {
+			query = query.Where("file_index_id IN (?)",
+				db.Model(&models.FileSummary{}).Select("file_index_id").Where("prompt_version = ?", version))
+		}
+		err := query.Group("file_index_id").Order("file_index_id").Limit(regenerateScan).Scan(&tagged).Error
+		if err != nil {
+			return result, err
+		}
+		if len(tagged) == 0 {
+			return result, nil
+		}
+
+		for _, t := range tagged {
+			lastID = t.FileIndexID
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			var file models.FileIndex
+			if err := db.First(&file, t.FileIndexID).Error; err != nil {
+				continue
+			}
+			// Refused files keep their statistical keywords
+			if fi.refuses(file) {
+				continue
+			}
+
+			current, err := fi.tagger.Version(ctx, file)
+			if err != nil {
+				return result, err
+			}
+			if t.Versions == 1 && t.Version == current {
+				continue
+			}
+
+			if result.Regenerated+result.Failed >= limit {
+				result.More = true
+				return result, nil
+			}
+
+			if err := fi.regenerateFile(ctx, file); err != nil {
+				result.Failed++
+				if fi.verbose {
+					fmt.Printf("Regenerating keywords for %s failed: %v\n", file.FilePath, err)
+				}
+				if errors.Is(err, ollama.ErrUnavailable) {
+					return result, err
+				}
+				continue
+			}
+			result.Regenerated++
+		}
+	}
+}
+
+// regenerateFile replaces file's keywords with freshly generated ones,
+// queueing the file for retry if generation fails.
+func (fi *FileIndexer) regenerateFile(ctx context.Context, file models.FileIndex) error {
+	content, err := fi.pendingContent(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	file, content, allowed := fi.redact(ctx, file, content)
+	if !allowed {
+		return fi.enrichLocally(ctx, file, content)
+	}
+
+	tagged, err := fi.tagger.Tag(ctx, file, file.Hash, content)
+	if err != nil {
+		fi.queue(ctx, file, 1, err)
+		return err
+	}
+
+	return fi.saveKeywords(ctx, file, tagged)
+}
@@ -0,0 +1,30 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+
+	"prabandh/llm/tagger"
+	"prabandh/models"
+	"prabandh/pkg/redact"
+)
+
+// ErrSensitive is returned by Tag when nothing from a file may be sent to the
+// model server.
+var ErrSensitive = errors.New("file is sensitive and may not be sent to the model server")
+
+// Tag returns keywords for content supplied for file rather than read from
+// disk. hash identifies the content for caching. The content and the file's
+// name and path are masked as the redaction mode requires.
+func (fi *FileIndexer) Tag(ctx context.Context, file models.FileIndex, hash, content string) (*tagger.Result, error) {
+	if fi.redactMode != redact.ModeOff {
+		masked, findings := redact.Mask(content)
+		outgoing, pathSensitive := maskPath(file)
+		outgoing.Sensitive = file.Sensitive || pathSensitive || redact.Sensitive(findings)
+		if fi.refuses(outgoing) {
+			return nil, ErrSensitive
+		}
+		file, content = outgoing, masked
+	}
+	return fi.tagger.Tag(ctx, file, hash, content)
+}
@@ -0,0 +1,86 @@
+package keywords
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"prabandh/pkg/rake"
+
+	"gorm.io/gorm"
+)
+
+// ExtractorVersion is stored as the prompt version of keywords produced by
+// an Extractor, so they can be told apart from LLM keywords and regenerated.
+const ExtractorVersion = "statistical@1"
+
+// candidatesPerKeyword is how many RAKE candidates are weighed per keyword
+// returned, so common phrases can be outranked by distinctive ones.
+const candidatesPerKeyword = 3
+
+// Extractor picks keywords without a language model. Candidate phrases are
+// found by RAKE within the file and weighted by their inverse document
+// frequency across the indexed content, so phrases common to every file (a
+// letterhead, a licence header) rank below the ones that set this file apart.
+type Extractor struct {
+	db *gorm.DB
+}
+
+func NewExtractor(db *gorm.DB) *Extractor {
+	return &Extractor{db: db}
+}
+
+// WithContext returns a copy of the extractor whose queries use ctx.
+func (e *Extractor) WithContext(ctx context.Context) *Extractor {
+	return &Extractor{db: e.db.WithContext(ctx)}
+}
+
+// Extract returns up to n keywords for content, best first.
+func (e *Extractor) Extract(content string, n int) ([]string, error) {
+	candidates := rake.Extract(content, n*candidatesPerKeyword)
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	phrases := make([]string, len(candidates))
+	for i, c := range candidates {
+		phrases[i] = c.Text
+	}
+
+	var total int64
+	if err := e.db.Table("file_contents").Where("deleted_at IS NULL").Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var frequencies []struct {
+		Phrase    string
+		Documents int64
+	}
+	err := e.db.Raw(`
+		SELECT t.phrase, (
+			SELECT COUNT(*) FROM file_contents c
+			WHERE c.deleted_at IS NULL AND c.content_tsv @@ multilingual_phrase_query(t.phrase)
+		) AS documents
+		FROM unnest(ARRAY[?]::text[]) AS t(phrase)`, phrases).Scan(&frequencies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make(map[string]int64, len(frequencies))
+	for _, f := range frequencies {
+		documents[f.Phrase] = f.Documents
+	}
+
+	// Smoothed IDF: a phrase in every document keeps a small positive weight
+	for i := range candidates {
+		idf := math.Log(float64(total+1)/float64(documents[candidates[i].Text]+1)) + 1
+		candidates[i].Score *= idf
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	keywords := make([]string, 0, n)
+	for _, c := range candidates[:min(n, len(candidates))] {
+		keywords = append(keywords, c.Text)
+	}
+	return keywords, nil
+}
@@ -0,0 +1,99 @@
+package keywords
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize folds a raw keyword into its display form: lower case, with
+// hyphens, underscores and other punctuation collapsed to single spaces, so
+// "Machine-Learning" and "machine_learning" both become "machine learning".
+func Normalize(raw string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(raw) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) || r == '+' || r == '#' {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Key returns the matching key for a keyword: its normalised form with every
+// word stemmed, so "invoices" and "invoicing" share the key "invoic".
+func Key(raw string) string {
+	words := strings.Fields(Normalize(raw))
+	for i, word := range words {
+		words[i] = stem(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// irregular maps common irregular plurals to their lemma.
+var irregular = map[string]string{
+	"analyses":  "analysis",
+	"children":  "child",
+	"criteria":  "criterion",
+	"indices":   "index",
+	"matrices":  "matrix",
+	"men":       "man",
+	"people":    "person",
+	"phenomena": "phenomenon",
+	"women":     "woman",
+}
+
+// stem is a light English suffix stripper covering plurals, -ing, -ed and a
+// trailing silent e. It is deliberately conservative: tags are short and
+// over-stemming merges unrelated terms.
+func stem(word string) string {
+	if lemma, ok := irregular[word]; ok {
+		word = lemma
+	}
+	if len(word) <= 3 || !isASCII(word) {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+	case strings.HasSuffix(word, "s"):
+		word = word[:len(word)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		base := strings.TrimSuffix(word, suffix)
+		if base == word || len(base) < 3 || !strings.ContainsAny(base, "aeiouy") {
+			continue
+		}
+		word = base
+		// "running" -> "runn" -> "run"
+		if n := len(word); word[n-1] == word[n-2] && !strings.ContainsRune("lsz", rune(word[n-1])) {
+			word = word[:n-1]
+		}
+		break
+	}
+
+	if len(word) >= 4 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+
+	return word
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package keywords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"Machine-Learning":        "machine learning",
+		" machine_learning. ":     "machine learning",
+		"C++":                     "c++",
+		"node.js":                 "node js",
+		"Künstliche  Intelligenz": "künstliche intelligenz",
+		"---":                     "",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, Normalize(input), "Normalize(%q)", input)
+	}
+}
+
+func TestKey(t *testing.T) {
+	same := [][]string{
+		{"machine learning", "Machine-Learning", "machine learnings"},
+		{"invoice", "invoices", "Invoicing", "invoiced"},
+		{"tax", "taxes"},
+		{"running", "runs", "run"},
+		{"category", "categories"},
+		{"analysis", "analyses"},
+	}
+	for _, group := range same {
+		for _, kw := range group[1:] {
+			assert.Equal(t, Key(group[0]), Key(kw), "%q and %q should share a key", group[0], kw)
+		}
+	}
+
+	assert.NotEqual(t, Key("business"), Key("bus"))
+	assert.Equal(t, "status", Key("status"))
+	assert.Equal(t, "दस्तावेज़", Key("दस्तावेज़"))
+}
@@ -0,0 +1,106 @@
+package keywords
+
+import (
+	"context"
+	"strings"
+
+	"prabandh/models"
+	"prabandh/pkg/sqlutil"
+
+	"gorm.io/gorm"
+)
+
+// Store manages the canonical keywords attached to indexed files.
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// WithContext returns a copy of s whose queries are bound to ctx.
+func (s *Store) WithContext(ctx context.Context) *Store {
+	return &Store{db: s.db.WithContext(ctx)}
+}
+
+// Count is a keyword with the number of files tagged with it.
+type Count struct {
+	Keyword string `json:"keyword"`
+	Count   int64  `json:"count"`
+}
+
+// Complete returns keywords whose name or synonym starts with prefix, most frequent first.
+func (s *Store) Complete(prefix string, limit int) ([]Count, error) {
+	pattern := sqlutil.EscapeLike(Normalize(prefix)) + "%"
+
+	var counts []Count
+	err := s.db.Raw(`
+		SELECT k.name AS keyword, COUNT(fk.file_index_id) AS count
+		FROM keywords k
+		LEFT JOIN file_keywords fk ON fk.keyword_id = k.id
+		WHERE k.deleted_at IS NULL AND (
+			k.name LIKE ? OR k.key LIKE ? OR k.id IN (
+				SELECT keyword_id FROM keyword_synonyms WHERE deleted_at IS NULL AND alias LIKE ?))
+		GROUP BY k.id, k.name
+		ORDER BY count DESC, keyword
+		LIMIT ?`, pattern, pattern, pattern, limit).Scan(&counts).Error
+	return counts, err
+}
+
+// Top returns the most frequent keywords, optionally restricted to files under indexDir.
+func (s *Store) Top(limit int, indexDir *models.IndexDir) ([]Count, error) {
+	tx := s.db.Table("keywords k").
+		Select("k.name AS keyword, COUNT(DISTINCT fk.file_index_id) AS count").
+		Joins("JOIN file_keywords fk ON fk.keyword_id = k.id").
+		Where("k.deleted_at IS NULL")
+
+	if indexDir != nil {
+		dir := strings.TrimSuffix(indexDir.DirectoryLocation, "/")
+		tx = tx.Joins("JOIN file_indices f ON f.id = fk.file_index_id AND f.deleted_at IS NULL").
+			Where("f.file_path LIKE ?", sqlutil.EscapeLike(dir)+"/%")
+	}
+
+	var counts []Count
+	err := tx.Group("k.id, k.name").
+		Order("count DESC, keyword").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Related returns keywords that tag the same files as keyword, ordered by how many files they share.
+func (s *Store) Related(keyword string, limit int) ([]Count, error) {
+	target, err := s.lookup(keyword)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []Count
+	err = s.db.Raw(`
+		SELECT k.name AS keyword, COUNT(DISTINCT b.file_index_id) AS count
+		FROM file_keywords a
+		JOIN file_keywords b ON b.file_index_id = a.file_index_id AND b.keyword_id <> a.keyword_id
+		JOIN keywords k ON k.id = b.keyword_id AND k.deleted_at IS NULL
+		WHERE a.keyword_id = ?
+		GROUP BY k.id, k.name
+		ORDER BY count DESC, keyword
+		LIMIT ?`, target, limit).Scan(&counts).Error
+	return counts, err
+}
+
+// lookup returns the ID of the canonical keyword for raw without creating it; zero if unknown.
+func (s *Store) lookup(raw string) (uint, error) {
+	key := Key(raw)
+
+	var ids []uint
+	err := s.db.Raw(`
+		SELECT id FROM keywords WHERE deleted_at IS NULL AND key = ?
+		UNION ALL
+		SELECT keyword_id FROM keyword_synonyms WHERE deleted_at IS NULL AND alias = ?
+		LIMIT 1`, key, key).Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
@@ -0,0 +1,207 @@
+package keywords
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"prabandh/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrEmptyKeyword is returned for keywords that normalise to nothing.
+	ErrEmptyKeyword = errors.New("keyword is empty after normalisation")
+	// ErrSameKeyword is returned when a synonym would map a keyword onto itself.
+	ErrSameKeyword = errors.New("alias and canonical keyword are the same")
+)
+
+// Resolve returns the canonical keyword for raw, following synonyms and
+// creating the keyword if it has not been seen before.
+func (s *Store) Resolve(raw string) (*models.Keyword, error) {
+	key := Key(raw)
+	if key == "" {
+		return nil, ErrEmptyKeyword
+	}
+
+	var synonym models.KeywordSynonym
+	err := s.db.Where("alias = ?", key).First(&synonym).Error
+	if err == nil {
+		var keyword models.Keyword
+		if err := s.db.First(&keyword, synonym.KeywordID).Error; err != nil {
+			return nil, err
+		}
+		return &keyword, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	keyword := models.Keyword{Name: Normalize(raw), Key: key}
+	err = s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoNothing: true,
+	}).Create(&keyword).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Another writer may have created it first
+	if keyword.ID == 0 {
+		if err := s.db.Where("key = ?", key).First(&keyword).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &keyword, nil
+}
+
+// Attach links a file to the canonical keywords for raw, skipping empty ones.
+func (s *Store) Attach(fileIndexID uint, raw []string) error {
+	var links []models.FileKeyword
+	seen := make(map[uint]bool)
+	for _, r := range raw {
+		keyword, err := s.Resolve(r)
+		if errors.Is(err, ErrEmptyKeyword) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !seen[keyword.ID] {
+			seen[keyword.ID] = true
+			links = append(links, models.FileKeyword{FileIndexID: fileIndexID, KeywordID: keyword.ID})
+		}
+	}
+
+	if len(links) == 0 {
+		return nil
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+// Synonym is an alias together with the canonical keyword it resolves to.
+type Synonym struct {
+	ID        uint   `json:"id"`
+	Alias     string `json:"alias"`
+	KeywordID uint   `json:"keyword_id"`
+	Keyword   string `json:"keyword"`
+}
+
+// Synonyms lists every alias with its canonical keyword.
+func (s *Store) Synonyms() ([]Synonym, error) {
+	var synonyms []Synonym
+	err := s.db.Raw(`
+		SELECT ks.id, ks.alias, ks.keyword_id, k.name AS keyword
+		FROM keyword_synonyms ks
+		JOIN keywords k ON k.id = ks.keyword_id
+		WHERE ks.deleted_at IS NULL
+		ORDER BY k.name, ks.alias`).Scan(&synonyms).Error
+	return synonyms, err
+}
+
+// AddSynonym makes alias resolve to canonical. If alias already exists as a
+// keyword it is merged into canonical: its files, and any synonyms pointing at
+// it, move over, so the merge applies retroactively to every tagged file.
+func (s *Store) AddSynonym(alias, canonical string) (*Synonym, error) {
+	aliasKey := Key(alias)
+	if aliasKey == "" {
+		return nil, ErrEmptyKeyword
+	}
+
+	var result *Synonym
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		store := &Store{db: tx}
+
+		target, err := store.Resolve(canonical)
+		if err != nil {
+			return err
+		}
+		if target.Key == aliasKey {
+			return fmt.Errorf("%w: %q and %q share the key %q", ErrSameKeyword, alias, canonical, aliasKey)
+		}
+
+		var existing models.Keyword
+		err = tx.Where("key = ?", aliasKey).First(&existing).Error
+		switch {
+		case err == nil:
+			if err := store.merge(existing.ID, target.ID); err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		synonym := models.KeywordSynonym{Alias: aliasKey, KeywordID: target.ID}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "alias"}},
+			DoUpdates: clause.AssignmentColumns([]string{"keyword_id", "updated_at"}),
+		}).Create(&synonym).Error
+		if err != nil {
+			return err
+		}
+
+		result = &Synonym{ID: synonym.ID, Alias: aliasKey, KeywordID: target.ID, Keyword: target.Name}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// merge moves every file and synonym of keyword from onto keyword into, then deletes from.
+func (s *Store) merge(from, into uint) error {
+	err := s.db.Exec(`
+		INSERT INTO file_keywords (file_index_id, keyword_id)
+		SELECT file_index_id, ? FROM file_keywords WHERE keyword_id = ?
+		ON CONFLICT DO NOTHING`, into, from).Error
+	if err != nil {
+		return err
+	}
+	if err := s.db.Where("keyword_id = ?", from).Delete(&models.FileKeyword{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Model(&models.KeywordSynonym{}).Where("keyword_id = ?", from).Update("keyword_id", into).Error; err != nil {
+		return err
+	}
+	return s.db.Unscoped().Delete(&models.Keyword{}, from).Error
+}
+
+// DeleteSynonym removes an alias. Keywords already merged by it stay merged.
+func (s *Store) DeleteSynonym(id uint) error {
+	result := s.db.Unscoped().Delete(&models.KeywordSynonym{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Rebuild links every file to canonical keywords derived from its FileSummary
+// rows, splitting legacy comma-joined rows. It returns the number of files processed.
+func (s *Store) Rebuild() (int, error) {
+	var summaries []models.FileSummary
+	if err := s.db.Order("file_index_id").Find(&summaries).Error; err != nil {
+		return 0, err
+	}
+
+	byFile := make(map[uint][]string)
+	var order []uint
+	for _, summary := range summaries {
+		if _, ok := byFile[summary.FileIndexID]; !ok {
+			order = append(order, summary.FileIndexID)
+		}
+		byFile[summary.FileIndexID] = append(byFile[summary.FileIndexID], strings.Split(summary.SummaryKeyword, ",")...)
+	}
+
+	for _, fileIndexID := range order {
+		if err := s.Attach(fileIndexID, byFile[fileIndexID]); err != nil {
+			return 0, err
+		}
+	}
+	return len(order), nil
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"prabandh/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lookups made by this process, shared by every Cache.
+var (
+	hits   atomic.Int64
+	misses atomic.Int64
+)
+
+// Cache is a content-addressed store of LLM-generated keywords keyed by
+// content hash, model and prompt version.
+type Cache struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Cache {
+	return &Cache{db: db}
+}
+
+// WithContext returns a copy of c whose queries are bound to ctx.
+func (c *Cache) WithContext(ctx context.Context) *Cache {
+	return &Cache{db: c.db.WithContext(ctx)}
+}
+
+// Get returns the cached keywords for the key, recording a hit or miss.
+func (c *Cache) Get(hash, model, promptVersion string) ([]string, bool, error) {
+	var entry models.KeywordCache
+	err := c.db.Where("hash = ? AND model_name = ? AND prompt_version = ?", hash, model, promptVersion).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		misses.Add(1)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	hits.Add(1)
+	if err := c.db.Model(&entry).UpdateColumn("hits", gorm.Expr("hits + 1")).Error; err != nil {
+		return nil, false, err
+	}
+	return strings.Split(entry.Keywords, "\n"), true, nil
+}
+
+// Put stores keywords for the key, replacing any previous entry.
+func (c *Cache) Put(hash, model, promptVersion string, keywords []string) error {
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	entry := models.KeywordCache{
+		Hash:          hash,
+		ModelName:     model,
+		PromptVersion: promptVersion,
+		Keywords:      strings.Join(keywords, "\n"),
+	}
+	return c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}, {Name: "model_name"}, {Name: "prompt_version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"keywords", "updated_at", "deleted_at"}),
+	}).Create(&entry).Error
+}
+
+// Filter selects cache entries; empty fields match everything.
+type Filter struct {
+	Hash          string `form:"hash" json:"hash"`
+	ModelName     string `form:"model" json:"model"`
+	PromptVersion string `form:"prompt_version" json:"prompt_version"`
+}
+
+// Invalidate deletes the entries matching filter and returns how many were removed.
+func (c *Cache) Invalidate(filter Filter) (int64, error) {
+	tx := c.db.Unscoped().Where("1 = 1")
+	if filter.Hash != "" {
+		tx = tx.Where("hash = ?", filter.Hash)
+	}
+	if filter.ModelName != "" {
+		tx = tx.Where("model_name = ?", filter.ModelName)
+	}
+	if filter.PromptVersion != "" {
+		tx = tx.Where("prompt_version = ?", filter.PromptVersion)
+	}
+
+	result := tx.Delete(&models.KeywordCache{})
+	return result.RowsAffected, result.Error
+}
+
+// ModelStats summarises the entries produced by one model and prompt version.
+type ModelStats struct {
+	ModelName     string `json:"model"`
+	PromptVersion string `json:"prompt_version"`
+	Entries       int64  `json:"entries"`
+	Hits          int64  `json:"hits"`
+}
+
+// Stats reports cache usage: stored entries and their lifetime hits, plus the
+// hits and misses seen by this process since it started.
+type Stats struct {
+	Entries       int64        `json:"entries"`
+	StoredHits    int64        `json:"stored_hits"`
+	ProcessHits   int64        `json:"process_hits"`
+	ProcessMisses int64        `json:"process_misses"`
+	HitRate       float64      `json:"hit_rate"`
+	ByModel       []ModelStats `json:"by_model"`
+}
+
+func (c *Cache) Stats() (*Stats, error) {
+	stats := &Stats{
+		ProcessHits:   hits.Load(),
+		ProcessMisses: misses.Load(),
+		ByModel:       []ModelStats{},
+	}
+	if lookups := stats.ProcessHits + stats.ProcessMisses; lookups > 0 {
+		stats.HitRate = float64(stats.ProcessHits) / float64(lookups)
+	}
+
+	err := c.db.Model(&models.KeywordCache{}).
+		Select("model_name, prompt_version, COUNT(*) AS entries, COALESCE(SUM(hits), 0) AS hits").
+		Group("model_name, prompt_version").
+		Order("model_name, prompt_version").
+		Scan(&stats.ByModel).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range stats.ByModel {
+		stats.Entries += m.Entries
+		stats.StoredHits += m.Hits
+	}
+	return stats, nil
+}
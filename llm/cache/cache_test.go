@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB returns a Postgres-dialect gorm.DB backed by sqlmock. Unmet
+// expectations fail the test.
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		sqlDB.Close()
+	})
+	return db, mock
+}
+
+var (
+	getQuery    = regexp.QuoteMeta(`SELECT * FROM "keyword_caches" WHERE (hash = $1 AND model_name = $2 AND prompt_version = $3)`)
+	hitUpdate   = regexp.QuoteMeta(`UPDATE "keyword_caches" SET "hits"=hits + 1`)
+	insertEntry = regexp.QuoteMeta(`INSERT INTO "keyword_caches"`)
+)
+
+func TestGet_Hit(t *testing.T) {
+	db, mock := newMockDB(t)
+	hits.Store(0)
+
+	mock.ExpectQuery(getQuery).
+		WithArgs("abc", "llama3.2", "keywords@2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hash", "model_name", "prompt_version", "keywords", "hits"}).
+			AddRow(7, "abc", "llama3.2", "keywords@2", "invoice\ntax", 2))
+	mock.ExpectBegin()
+	mock.ExpectExec(hitUpdate).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	keywords, ok, err := New(db).Get("abc", "llama3.2", "keywords@2")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"invoice", "tax"}, keywords)
+	assert.Equal(t, int64(1), hits.Load())
+}
+
+func TestGet_MissOnOtherModelOrVersion(t *testing.T) {
+	db, mock := newMockDB(t)
+	misses.Store(0)
+
+	// Entries are looked up by the full key, so another model or prompt
+	// version finds nothing even for the same content
+	mock.ExpectQuery(getQuery).
+		WithArgs("abc", "mistral", "keywords@2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(getQuery).
+		WithArgs("abc", "llama3.2", "keywords@3", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	cache := New(db)
+	for _, key := range [][2]string{{"mistral", "keywords@2"}, {"llama3.2", "keywords@3"}} {
+		keywords, ok, err := cache.Get("abc", key[0], key[1])
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Nil(t, keywords)
+	}
+	assert.Equal(t, int64(2), misses.Load())
+}
+
+func TestPut(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertEntry+`.*`+regexp.QuoteMeta(`ON CONFLICT ("hash","model_name","prompt_version") DO UPDATE`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "abc", "llama3.2", "keywords@2", "invoice\ntax", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hits"}).AddRow(1, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, New(db).Put("abc", "llama3.2", "keywords@2", []string{"invoice", "tax"}))
+}
+
+func TestPut_NothingToStore(t *testing.T) {
+	db, _ := newMockDB(t)
+	assert.NoError(t, New(db).Put("abc", "llama3.2", "keywords@2", nil))
+}
+
+func TestInvalidate(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "keyword_caches" WHERE 1 = 1 AND model_name = $1 AND prompt_version = $2`)).
+		WithArgs("llama3.2", "keywords@1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	deleted, err := New(db).Invalidate(Filter{ModelName: "llama3.2", PromptVersion: "keywords@1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+}
+
+func TestStats(t *testing.T) {
+	db, mock := newMockDB(t)
+	hits.Store(3)
+	misses.Store(1)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT model_name, prompt_version, COUNT(*) AS entries, COALESCE(SUM(hits), 0) AS hits FROM "keyword_caches"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"model_name", "prompt_version", "entries", "hits"}).
+			AddRow("llama3.2", "keywords@1", 4, 10).
+			AddRow("llama3.2", "keywords@2", 2, 1))
+
+	stats, err := New(db).Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), stats.Entries)
+	assert.Equal(t, int64(11), stats.StoredHits)
+	assert.Equal(t, int64(3), stats.ProcessHits)
+	assert.Equal(t, int64(1), stats.ProcessMisses)
+	assert.InDelta(t, 0.75, stats.HitRate, 1e-9)
+	assert.Len(t, stats.ByModel, 2)
+}
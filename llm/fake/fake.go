@@ -0,0 +1,180 @@
+// Package fake is a deterministic stand-in for a language model, for
+// development and tests without a model server. Keywords are the RAKE key
+// phrases of the file content, embeddings hash the words of the text,
+// answers list the key phrases of the prompt, and images are described by
+// their format and size.
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	_ "image/gif" // Register decoders for the formats thumbnails are made from
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strings"
+	"unicode"
+
+	"prabandh/llm/generation"
+	"prabandh/models"
+	"prabandh/pkg/rake"
+)
+
+// Model names reported by the provider, so output it produced is cached and
+// stored apart from a real model's.
+const (
+	Model       = "fake-rake"
+	EmbedModel  = "fake-hash"
+	VisionModel = "fake-vision"
+)
+
+// DefaultKeywords is how many keywords ExtractKeywords returns at most.
+const DefaultKeywords = 10
+
+// answerPhrases is how many key phrases GenerateStream answers with.
+const answerPhrases = 5
+
+// contentMarker precedes the file content in the built-in prompt templates.
+const contentMarker = "Content:\n"
+
+// Provider implements llm.Provider without a model.
+type Provider struct {
+	Keywords int
+}
+
+func New() *Provider {
+	return &Provider{Keywords: DefaultKeywords}
+}
+
+func (p *Provider) ModelName() string {
+	return Model
+}
+
+func (p *Provider) EmbedModelName() string {
+	return EmbedModel
+}
+
+func (p *Provider) VisionModelName() string {
+	return VisionModel
+}
+
+// ExtractKeywords returns the key phrases of the file content in prompt,
+// which is everything after a "Content:" line as the built-in templates have
+// it, or the whole prompt otherwise.
+func (p *Provider) ExtractKeywords(ctx context.Context, prompt string) ([]string, *generation.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	phrases := rake.Extract(content(prompt), p.Keywords)
+	if len(phrases) == 0 {
+		return nil, nil, errors.New("no valid keywords generated")
+	}
+
+	keywords := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		keywords[i] = phrase.Text
+	}
+	return keywords, &generation.Stats{Model: Model, PromptEvalCount: len(strings.Fields(prompt))}, nil
+}
+
+// CompleteJSON decodes an empty object into out, leaving it zero: the fake
+// classifies nothing and finds no entities beyond pattern matching.
+func (p *Provider) CompleteJSON(ctx context.Context, prompt string, out interface{}) (*generation.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte("{}"), out); err != nil {
+		return nil, err
+	}
+	return &generation.Stats{Model: Model, PromptEvalCount: len(strings.Fields(prompt))}, nil
+}
+
+// GenerateStream streams the key phrases of prompt one word at a time.
+func (p *Provider) GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (*generation.Stats, error) {
+	var texts []string
+	for _, phrase := range rake.Extract(content(prompt), answerPhrases) {
+		texts = append(texts, phrase.Text)
+	}
+	answer := "Key phrases: " + strings.Join(texts, "; ") + "."
+
+	stats := &generation.Stats{Model: Model, PromptEvalCount: len(strings.Fields(prompt))}
+	for i, word := range strings.Fields(answer) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			word = " " + word
+		}
+		if err := onToken(word); err != nil {
+			return nil, err
+		}
+		stats.EvalCount++
+	}
+	return stats, nil
+}
+
+// DescribeImage describes an image by its format and dimensions, e.g.
+// "A 640x480 jpeg image.".
+func (p *Provider) DescribeImage(ctx context.Context, prompt string, img []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return "", fmt.Errorf("decode image: %w", err)
+	}
+	return fmt.Sprintf("A %dx%d %s image.", config.Width, config.Height, format), nil
+}
+
+// Embed hashes each word of text into one of models.EmbeddingDimensions
+// buckets and normalises the result, so texts sharing words are close.
+func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vector := make([]float64, models.EmbeddingDimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		// The top bit picks the sign so unrelated words tend to cancel out
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(len(vector))] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return nil, errors.New("empty embedding returned")
+	}
+
+	embedding := make([]float32, len(vector))
+	for i, v := range vector {
+		embedding[i] = float32(v / math.Sqrt(norm))
+	}
+	return embedding, nil
+}
+
+func content(prompt string) string {
+	if i := strings.LastIndex(prompt, contentMarker); i >= 0 {
+		return prompt[i+len(contentMarker):]
+	}
+	return prompt
+}
@@ -0,0 +1,104 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prabandh/models"
+)
+
+func TestExtractKeywordsUsesContent(t *testing.T) {
+	prompt := "Extract keywords from the file below.\nFile: q3.txt\nContent:\nQuarterly tax return for Acme Corp. The quarterly tax return is due in April."
+
+	keywords, _, err := New().ExtractKeywords(context.Background(), prompt)
+	if err != nil {
+		t.Fatalf("ExtractKeywords failed: %v", err)
+	}
+
+	want := []string{"quarterly tax return", "acme corp", "due", "april"}
+	if !reflect.DeepEqual(keywords, want) {
+		t.Errorf("Expected %v, got %v", want, keywords)
+	}
+}
+
+func TestEmbedIsDeterministic(t *testing.T) {
+	p := New()
+	a, err := p.Embed(context.Background(), "invoice from Acme")
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	b, _ := p.Embed(context.Background(), "Invoice from ACME")
+	c, _ := p.Embed(context.Background(), "holiday photos")
+
+	if len(a) != models.EmbeddingDimensions {
+		t.Fatalf("Expected %d dimensions, got %d", models.EmbeddingDimensions, len(a))
+	}
+	if cosine(a, b) < 0.999 {
+		t.Errorf("Expected texts differing in case to embed alike, got similarity %f", cosine(a, b))
+	}
+	if cosine(a, c) > 0.5 {
+		t.Errorf("Expected unrelated texts to be far apart, got similarity %f", cosine(a, c))
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	var answer strings.Builder
+	stats, err := New().GenerateStream(context.Background(), "Content:\nAnnual report for Acme Corp", func(token string) error {
+		answer.WriteString(token)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+
+	if answer.String() != "Key phrases: annual report; acme corp." {
+		t.Errorf("Unexpected answer %q", answer.String())
+	}
+	if stats.Model != Model || stats.EvalCount != 6 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestDescribeImage(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 64, 32))); err != nil {
+		t.Fatal(err)
+	}
+
+	description, err := New().DescribeImage(context.Background(), "Describe the image.", img.Bytes())
+	if err != nil {
+		t.Fatalf("DescribeImage failed: %v", err)
+	}
+	if description != "A 64x32 png image." {
+		t.Errorf("Unexpected description %q", description)
+	}
+
+	if _, err := New().DescribeImage(context.Background(), "Describe the image.", []byte("text")); err == nil {
+		t.Error("Expected an error for data that is not an image")
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := New().ExtractKeywords(ctx, "anything"); err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i] * b[i])
+		na += float64(a[i] * a[i])
+		nb += float64(b[i] * b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
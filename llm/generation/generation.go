@@ -0,0 +1,33 @@
+// Package generation holds the types that describe a text generation, shared
+// by every language model backend.
+package generation
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stats holds the token counts and timings of a generation. The JSON names
+// and nanosecond durations are those Ollama reports in its final chunk.
+type Stats struct {
+	Model              string        `json:"model"`
+	TotalDuration      time.Duration `json:"total_duration"`
+	LoadDuration       time.Duration `json:"load_duration"`
+	PromptEvalCount    int           `json:"prompt_eval_count"`
+	PromptEvalDuration time.Duration `json:"prompt_eval_duration"`
+	EvalCount          int           `json:"eval_count"`
+	EvalDuration       time.Duration `json:"eval_duration"`
+}
+
+// TokensPerSecond is the generation speed, excluding prompt evaluation.
+func (s *Stats) TokensPerSecond() float64 {
+	if s.EvalDuration <= 0 {
+		return 0
+	}
+	return float64(s.EvalCount) / s.EvalDuration.Seconds()
+}
+
+func (s *Stats) String() string {
+	return fmt.Sprintf("%d tokens in %s (%.1f tokens/s), prompt %d tokens",
+		s.EvalCount, s.EvalDuration.Round(time.Millisecond), s.TokensPerSecond(), s.PromptEvalCount)
+}
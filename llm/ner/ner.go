@@ -0,0 +1,196 @@
+// Package ner extracts named entities from file content, combining a pattern
+// matching pass for dates and amounts with the LLM in JSON mode.
+package ner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"prabandh/llm"
+	"prabandh/llm/prompt"
+	"prabandh/models"
+	"prabandh/pkg/entities"
+
+	"gorm.io/gorm"
+)
+
+// Entity sources.
+const (
+	SourcePattern = "pattern"
+	SourceLLM     = "llm"
+)
+
+// maxEntities bounds how many entities are stored per file, as pattern
+// matching over a long ledger can find thousands of amounts.
+const maxEntities = 200
+
+// Extractor finds the entities in a file and stores them.
+type Extractor struct {
+	db       *gorm.DB
+	provider llm.Provider
+	prompts  *prompt.Library
+}
+
+func New(db *gorm.DB, provider llm.Provider, prompts *prompt.Library) *Extractor {
+	return &Extractor{
+		db:       db,
+		provider: provider,
+		prompts:  prompts,
+	}
+}
+
+// response is the JSON the Entities prompt asks for.
+type response struct {
+	People        values `json:"people"`
+	Organizations values `json:"organizations"`
+	Places        values `json:"places"`
+	Dates         values `json:"dates"`
+	Amounts       values `json:"amounts"`
+}
+
+// values is a list of strings that also accepts numbers, which models
+// sometimes return for amounts.
+type values []string
+
+func (v *values) UnmarshalJSON(data []byte) error {
+	var raw []interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, item := range raw {
+		switch item := item.(type) {
+		case string:
+			*v = append(*v, item)
+		case float64:
+			*v = append(*v, strconv.FormatFloat(item, 'f', -1, 64))
+		}
+	}
+	return nil
+}
+
+// Extract replaces the stored entities of file with those found in content.
+// Dates and amounts found by pattern matching are stored even if the LLM
+// fails, in which case the entities it found previously are kept and its
+// error is returned.
+func (e *Extractor) Extract(ctx context.Context, file models.FileIndex, content string) ([]models.Entity, error) {
+	found := entities.Scan(content)
+	named, llmErr := e.complete(ctx, file, content, found)
+
+	records, err := e.store(ctx, file, found, named, llmErr == nil)
+	if err != nil {
+		return nil, err
+	}
+	return records, llmErr
+}
+
+// Patterns replaces the pattern-matched entities of file with the dates and
+// amounts found in content, without asking the LLM, for files whose content
+// may not be sent to it. Entities the LLM found previously are kept.
+func (e *Extractor) Patterns(ctx context.Context, file models.FileIndex, content string) ([]models.Entity, error) {
+	return e.store(ctx, file, entities.Scan(content), nil, false)
+}
+
+// store replaces the stored entities of file with the pattern-matched found
+// and the LLM's named. Unless replaceNamed is set, only entities from pattern
+// matching are replaced.
+func (e *Extractor) store(ctx context.Context, file models.FileIndex, found, named []entities.Entity, replaceNamed bool) ([]models.Entity, error) {
+	matched := make(map[string]bool, len(found))
+	for _, entity := range found {
+		matched[entity.Key()] = true
+	}
+
+	found = entities.Dedupe(append(found, named...))
+	if len(found) > maxEntities {
+		found = found[:maxEntities]
+	}
+
+	records := make([]models.Entity, len(found))
+	for i, entity := range found {
+		records[i] = record(file.ID, entity)
+		if !matched[entity.Key()] {
+			records[i].Source = SourceLLM
+		}
+	}
+
+	err := e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale := tx.Unscoped().Where("file_index_id = ?", file.ID)
+		if !replaceNamed {
+			stale = stale.Where("source = ?", SourcePattern)
+		}
+		if err := stale.Delete(&models.Entity{}).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&records, 100).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// complete asks the LLM for the entities in content, offering it what pattern
+// matching found. Entities it returns that pattern matching already found are
+// left for Dedupe to drop.
+func (e *Extractor) complete(ctx context.Context, file models.FileIndex, content string, found []entities.Entity) ([]entities.Entity, error) {
+	tmpl, ok := e.prompts.Get(prompt.Entities)
+	if !ok {
+		return nil, fmt.Errorf("prompt %q is not defined", prompt.Entities)
+	}
+
+	data := prompt.NewData(file, content)
+	for _, entity := range found {
+		data.Entities = append(data.Entities, prompt.Entity{Type: string(entity.Type), Value: entity.Value})
+	}
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp response
+	if _, err := e.provider.CompleteJSON(ctx, rendered, &resp); err != nil {
+		return nil, err
+	}
+
+	var named []entities.Entity
+	for _, group := range []struct {
+		t    entities.Type
+		list values
+	}{
+		{entities.Person, resp.People},
+		{entities.Organization, resp.Organizations},
+		{entities.Place, resp.Places},
+		{entities.Date, resp.Dates},
+		{entities.Amount, resp.Amounts},
+	} {
+		for _, text := range group.list {
+			// Dates and amounts the model could not write in a usable form are dropped
+			if entity, ok := entities.New(group.t, text); ok {
+				named = append(named, entity)
+			}
+		}
+	}
+	return named, nil
+}
+
+func record(fileID uint, entity entities.Entity) models.Entity {
+	r := models.Entity{
+		FileIndexID: fileID,
+		Type:        string(entity.Type),
+		Value:       entity.Value,
+		Text:        entity.Text,
+		Currency:    entity.Currency,
+		Source:      SourcePattern,
+	}
+	switch entity.Type {
+	case entities.Date:
+		r.Date = &entity.Date
+	case entities.Amount:
+		r.Amount = &entity.Amount
+	}
+	return r
+}
@@ -0,0 +1,79 @@
+package ner
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"prabandh/models"
+	"prabandh/pkg/entities"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestResponseAcceptsNumbers(t *testing.T) {
+	var resp response
+	err := json.Unmarshal([]byte(`{"people": ["Jane Doe"], "amounts": ["1250.00 EUR", 99.5, null]}`), &resp)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(resp.People, values{"Jane Doe"}) {
+		t.Errorf("Unexpected people: %v", resp.People)
+	}
+	if !reflect.DeepEqual(resp.Amounts, values{"1250.00 EUR", "99.5"}) {
+		t.Errorf("Unexpected amounts: %v", resp.Amounts)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	amount, _ := entities.New(entities.Amount, "€1,250")
+	r := record(7, amount)
+
+	if r.FileIndexID != 7 || r.Type != "amount" || r.Value != "1250.00 EUR" || r.Source != SourcePattern {
+		t.Errorf("Unexpected record: %+v", r)
+	}
+	if r.Amount == nil || *r.Amount != 1250 || r.Date != nil {
+		t.Errorf("Expected only the amount to be set, got amount=%v date=%v", r.Amount, r.Date)
+	}
+}
+
+func TestPatternsStoresWithoutLLM(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// Only pattern-matched entities are replaced; ones the LLM found
+	// before the file was refused are kept
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "entities" WHERE file_index_id = $1 AND source = $2`)).
+		WithArgs(7, SourcePattern).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "entities"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// A nil provider would panic if the LLM were asked
+	extractor := New(db, nil, nil)
+	records, err := extractor.Patterns(context.Background(), models.FileIndex{Model: gorm.Model{ID: 7}}, "Invoice total: €1,250")
+	if err != nil {
+		t.Fatalf("Patterns failed: %v", err)
+	}
+	if len(records) != 1 || records[0].Value != "1250.00 EUR" || records[0].Source != SourcePattern {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
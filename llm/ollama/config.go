@@ -0,0 +1,46 @@
+package ollama
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Defaults used when the corresponding environment variables are unset.
+const (
+	DefaultURL   = "http://localhost:11434"
+	DefaultModel = "gemma:2b"
+)
+
+// NewFromEnv creates a client configured by the environment:
+//
+//	OLLAMA_URL              server address (DefaultURL)
+//	OLLAMA_MODEL            primary generation model (DefaultModel)
+//	OLLAMA_FALLBACK_MODELS  comma-separated models tried in order when the primary fails
+//	OLLAMA_EMBED_MODEL      embedding model (DefaultEmbedModel)
+//	OLLAMA_VISION_MODEL     multimodal model describing images (DefaultVisionModel)
+func NewFromEnv() *Client {
+	c := New(envOr("OLLAMA_URL", DefaultURL), envOr("OLLAMA_MODEL", DefaultModel))
+	c.EmbedModel = envOr("OLLAMA_EMBED_MODEL", DefaultEmbedModel)
+	c.VisionModel = envOr("OLLAMA_VISION_MODEL", DefaultVisionModel)
+
+	for _, model := range strings.Split(os.Getenv("OLLAMA_FALLBACK_MODELS"), ",") {
+		if model = strings.TrimSpace(model); model != "" && model != c.Model {
+			c.Fallbacks = append(c.Fallbacks, model)
+		}
+	}
+	return c
+}
+
+// PullFromEnv reports whether OLLAMA_PULL asks for missing models to be pulled at startup.
+func PullFromEnv() bool {
+	pull, _ := strconv.ParseBool(os.Getenv("OLLAMA_PULL"))
+	return pull
+}
+
+func envOr(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,217 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNoModel is returned by EnsureModels when neither the primary model nor
+// any fallback is available.
+var ErrNoModel = errors.New("no generation model available")
+
+// ModelInfo describes a model installed on the Ollama server.
+type ModelInfo struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Digest     string    `json:"digest"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// PullProgress is a progress update streamed while a model is pulled.
+type PullProgress struct {
+	Model     string `json:"-"`
+	Status    string `json:"status"`
+	Digest    string `json:"digest"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+}
+
+// Percent reports how much of the current layer has been downloaded, or -1
+// when the update carries no size.
+func (p PullProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Completed) / float64(p.Total) * 100
+}
+
+// ModelStatus reports which of the client's models the server has.
+type ModelStatus struct {
+	Available []string `json:"available"`
+	Missing   []string `json:"missing"`
+	Pulled    []string `json:"pulled"`
+}
+
+// models returns the generation models to try, in order.
+func (c *Client) models() []string {
+	return append([]string{c.Model}, c.Fallbacks...)
+}
+
+// ListModels returns the models installed on the Ollama server.
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("request error: %w", err)
+	}
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var response struct {
+		Models []ModelInfo `json:"models"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("decode error: %w", err)
+	}
+
+	return response.Models, nil
+}
+
+// PullModel downloads model to the Ollama server, calling onProgress (if not
+// nil) with each progress update until the pull completes or ctx is cancelled.
+func (c *Client) PullModel(ctx context.Context, model string, onProgress func(PullProgress)) error {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"name":   model,
+		"stream": true,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/pull", bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Pulls can take a long time, so only ctx bounds them
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var progress struct {
+			PullProgress
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&progress); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return ErrIncompleteStream
+			}
+			return fmt.Errorf("decode error: %w", err)
+		}
+
+		if progress.Error != "" {
+			return fmt.Errorf("pull %s: %s", model, progress.Error)
+		}
+
+		progress.Model = model
+		if onProgress != nil {
+			onProgress(progress.PullProgress)
+		}
+
+		if progress.Status == "success" {
+			return nil
+		}
+	}
+}
+
+// EnsureModels checks which of the client's generation models and its embed
+// model are installed, pulling missing ones first when pull is true. Models
+// that remain unavailable are dropped from the fallback chain so calls go
+// straight to one that works; if none is left it returns ErrNoModel. A missing
+// embed model is reported but is not an error, since semantic search is
+// optional.
+func (c *Client) EnsureModels(ctx context.Context, pull bool, onProgress func(PullProgress)) (*ModelStatus, error) {
+	installed, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ollama unreachable at %s: %w", c.BaseURL, err)
+	}
+
+	names := make(map[string]bool, len(installed))
+	for _, m := range installed {
+		names[m.Name] = true
+	}
+
+	status := &ModelStatus{
+		Available: []string{},
+		Missing:   []string{},
+		Pulled:    []string{},
+	}
+	check := func(model string) bool {
+		if hasModel(names, model) {
+			status.Available = append(status.Available, model)
+			return true
+		}
+		if pull {
+			if err := c.PullModel(ctx, model, onProgress); err == nil {
+				status.Pulled = append(status.Pulled, model)
+				status.Available = append(status.Available, model)
+				return true
+			}
+		}
+		status.Missing = append(status.Missing, model)
+		return false
+	}
+
+	var usable []string
+	for _, model := range c.models() {
+		if check(model) {
+			usable = append(usable, model)
+		}
+	}
+	if c.EmbedModel != "" {
+		check(c.EmbedModel)
+	}
+
+	if len(usable) == 0 {
+		return status, fmt.Errorf("%w: missing %s (run `ollama pull %s` or set OLLAMA_PULL=true)",
+			ErrNoModel, strings.Join(c.models(), ", "), c.Model)
+	}
+
+	c.Model, c.Fallbacks = usable[0], usable[1:]
+	return status, nil
+}
+
+// hasModel reports whether model is among the installed names, treating a
+// name without a tag as ":latest" as Ollama does.
+func hasModel(installed map[string]bool, model string) bool {
+	if installed[model] {
+		return true
+	}
+	if !strings.Contains(model, ":") {
+		return installed[model+":latest"]
+	}
+	return strings.HasSuffix(model, ":latest") && installed[strings.TrimSuffix(model, ":latest")]
+}
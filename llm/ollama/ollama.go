@@ -2,6 +2,7 @@ package ollama
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,94 +10,168 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"prabandh/llm/generation"
+	"prabandh/pkg/resilience"
+	"prabandh/pkg/textutil"
 )
 
+// DefaultEmbedModel produces models.EmbeddingDimensions-sized vectors.
+const DefaultEmbedModel = "nomic-embed-text"
+
+// maxEmbedBytes bounds the text sent for embedding.
+const maxEmbedBytes = 10000
+
+// DefaultVisionModel is a multimodal model that describes images.
+const DefaultVisionModel = "llava"
+
 type Client struct {
-	BaseURL string
-	Model   string
-	Timeout time.Duration
+	BaseURL     string
+	Model       string
+	Fallbacks   []string // Models tried in order when Model fails
+	EmbedModel  string
+	VisionModel string // Describes images; not checked by EnsureModels, as captions are opt-in
+	Timeout     time.Duration
+
+	// Shared by every call made through the client
+	Breaker *resilience.Breaker
+	Limiter *resilience.Limiter
+	Backoff resilience.Backoff
 }
 
 func New(baseURL, model string) *Client {
 	return &Client{
-		BaseURL: strings.TrimSuffix(baseURL, "/"),
-		Model:   model,
-		Timeout: 300 * time.Second,
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+		Model:       model,
+		EmbedModel:  DefaultEmbedModel,
+		VisionModel: DefaultVisionModel,
+		Timeout:     300 * time.Second,
+		Breaker:     resilience.NewBreaker(breakerThreshold, BreakerCooldown),
+		Limiter:     resilience.NewLimiter(limiterMinRate, limiterMaxRate, limiterBurst),
+		Backoff:     defaultBackoff,
 	}
 }
 
-func (c *Client) ExtractKeywords(text string) ([]string, error) {
-	if len(text) > 10000 {
-		text = text[:10000]
-	}
+// ModelName is the primary generation model.
+func (c *Client) ModelName() string {
+	return c.Model
+}
 
-	prompt := `You are tasked with being a search optimizer. Given the text content of a file and its metadata (such as creation date, path, file name, etc.), generate only 5-10 relevant keywords that can help users search for this file efficiently. Return the keywords as a list where each keyword is prefixed with a '-'. Example output: - academics - module1 - sem4 - os
-Text: ` + text
+// EmbedModelName is the model used by Embed.
+func (c *Client) EmbedModelName() string {
+	return c.EmbedModel
+}
 
-	requestBody := map[string]interface{}{
-		"model":  c.Model,
-		"prompt": prompt,
-		"stream": false,
-		"options": map[string]interface{}{
-			"temperature": 0.3,
-		},
-	}
+// VisionModelName is the model used by DescribeImage.
+func (c *Client) VisionModelName() string {
+	return c.VisionModel
+}
 
-	jsonData, err := json.Marshal(requestBody)
+// keywordLine matches a "- keyword" line of a keyword response. Keywords are
+// words in any script, so marks such as Devanagari vowel signs are allowed.
+var keywordLine = regexp.MustCompile(`(?m)^-[ \t]*([\p{L}\p{N}_][\p{L}\p{M}\p{N}_ \t]*)$`)
+
+// ExtractKeywords sends a rendered keyword prompt (see package prompt) and
+// parses the "- keyword" lines of the response. The call is bounded by the
+// client's Timeout and aborted when ctx is cancelled. The returned Stats name
+// the model that answered, which is a fallback model if Model failed.
+func (c *Client) ExtractKeywords(ctx context.Context, prompt string) ([]string, *generation.Stats, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	response, stats, err := c.Complete(ctx, prompt, keywordTemperature)
 	if err != nil {
-		return nil, fmt.Errorf("marshal error: %w", err)
+		return nil, nil, err
 	}
 
-	client := &http.Client{Timeout: c.Timeout}
-	resp, err := client.Post(
-		c.BaseURL+"/api/generate",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("API request failed: %w", err)
+	// Extract keywords from lines starting with "- "
+	matches := keywordLine.FindAllStringSubmatch(response, -1)
+
+	var keywords []string
+	for _, match := range matches {
+		kw := strings.TrimSpace(match[1])
+		kw = strings.ToLower(kw)
+		kw = strings.Trim(kw, `.,;:"'!?`)
+		if utf8.RuneCountInString(kw) > 2 { // Minimum keyword length
+			keywords = append(keywords, kw)
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	if len(keywords) == 0 {
+		return nil, nil, fmt.Errorf("no valid keywords generated")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	return keywords, stats, nil
+}
+
+// Embed returns the embedding vector for text using the client's EmbedModel.
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
+	text = textutil.Truncate(text, maxEmbedBytes)
+
+	requestBody := map[string]interface{}{
+		"model":  c.EmbedModel,
+		"prompt": text,
 	}
 
 	var response struct {
-		Response string `json:"response"`
-		Error    string `json:"error"`
+		Embedding []float32 `json:"embedding"`
+		Error     string    `json:"error"`
 	}
-
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("decode error: %w", err)
+	if err := c.post(ctx, "/api/embeddings", requestBody, &response); err != nil {
+		return nil, err
 	}
 
 	if response.Error != "" {
 		return nil, fmt.Errorf("model error: %s", response.Error)
 	}
 
-	// Extract keywords with regex for lines starting with "- "
-	re := regexp.MustCompile(`(?m)^-\s*(\w[\w\s]*)$`)
-	matches := re.FindAllStringSubmatch(response.Response, -1)
+	if len(response.Embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
 
-	var keywords []string
-	for _, match := range matches {
-		kw := strings.TrimSpace(match[1])
-		kw = strings.ToLower(kw)
-		kw = strings.Trim(kw, `.,;:"'!?`)
-		if len(kw) > 2 { // Minimum keyword length
-			keywords = append(keywords, kw)
-		}
+	return response.Embedding, nil
+}
+
+// post sends a JSON request to the Ollama API through the client's breaker and
+// limiter and decodes the JSON response into out.
+func (c *Client) post(ctx context.Context, path string, requestBody interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
 	}
 
-	if len(keywords) == 0 {
-		return nil, fmt.Errorf("no valid keywords generated")
+	return c.call(ctx, func() error {
+		return c.postOnce(ctx, path, jsonData, out)
+	})
+}
+
+func (c *Client) postOnce(ctx context.Context, path string, jsonData []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode error: %w", err)
 	}
 
-	return keywords, nil
+	return nil
 }
@@ -1,24 +1,31 @@
 package ollama
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestExtractKeywords(t *testing.T) {
-	mockResponse := `{"response": "test, sample, data, keyword, check"}`
+	// Generation is streamed as one JSON object per line
+	mockResponse := `{"response": "- test\n- sample\n", "done": false}
+{"response": "- data\n- keyword\n- check", "done": false}
+{"response": "", "done": true, "eval_count": 12}
+`
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(mockResponse))
 	}))
 	defer server.Close()
 
-	client := New(server.URL)
+	client := New(server.URL, DefaultModel)
 
-	keywords, err := client.ExtractKeywords("AI in healthcare is revolutionizing data analysis.")
+	keywords, _, err := client.ExtractKeywords(context.Background(), "AI in healthcare is revolutionizing data analysis.")
 	t.Logf("Received Keywords: %v", keywords)
 
 	assert.NoError(t, err, "Expected no error")
@@ -32,26 +39,56 @@ func TestExtractKeywords_Failure(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(server.URL)
+	client := New(server.URL, DefaultModel)
+	client.Backoff.Attempts = 1
 
-	_, err := client.ExtractKeywords("Some sample text")
+	_, _, err := client.ExtractKeywords(context.Background(), "Some sample text")
 	assert.Error(t, err, "Expected an error when the server fails")
 }
 
+func TestExtractKeywords_ReportsFallbackModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model == DefaultModel {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "model not found"}`))
+			return
+		}
+		w.Write([]byte(`{"response": "- invoice\n", "done": false}
+{"response": "", "done": true}
+`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, DefaultModel)
+	client.Fallbacks = []string{"fallback-model"}
+	client.Backoff.Attempts = 1
+
+	keywords, stats, err := client.ExtractKeywords(context.Background(), "Invoice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"invoice"}, keywords)
+	if assert.NotNil(t, stats) {
+		assert.Equal(t, "fallback-model", stats.Model, "keywords should be attributed to the model that answered")
+	}
+}
+
 func TestExtractKeywords_EmptyResponse(t *testing.T) {
-	mockResponse := `{"response": ""}`
+	mockResponse := `{"response": "", "done": true}`
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(mockResponse))
 	}))
 	defer server.Close()
 
-	client := New(server.URL)
+	client := New(server.URL, DefaultModel)
 
-	keywords, err := client.ExtractKeywords("Text with no keywords")
+	keywords, _, err := client.ExtractKeywords(context.Background(), "Text with no keywords")
 	t.Logf("Received Keywords (empty case): %v", keywords)
 
-	assert.NoError(t, err, "Expected no error for empty response")
+	assert.Error(t, err, "Expected an error for a response without keywords")
 	assert.Empty(t, keywords, "Expected empty keyword list")
 }
 
@@ -63,8 +100,67 @@ func TestExtractKeywords_MalformedResponse(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(server.URL)
+	client := New(server.URL, DefaultModel)
 
-	_, err := client.ExtractKeywords("Sample text")
+	_, _, err := client.ExtractKeywords(context.Background(), "Sample text")
 	assert.Error(t, err, "Expected an error for malformed JSON response")
 }
+
+func TestExtractKeywords_Unicode(t *testing.T) {
+	mockResponse := `{"response": "- Rechnungsprüfung\n- चालान भुगतान\n- straße\n- no-hyphens\n- ab", "done": true}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, DefaultModel)
+
+	keywords, _, err := client.ExtractKeywords(context.Background(), "Rechnung")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rechnungsprüfung", "चालान भुगतान", "straße"}, keywords)
+}
+
+func TestDescribeImage(t *testing.T) {
+	var request struct {
+		Model  string   `json:"model"`
+		Images []string `json:"images"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"response": " A cat on a ", "done": false}
+{"response": "windowsill. ", "done": true}
+`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, DefaultModel)
+
+	description, err := client.DescribeImage(context.Background(), "Describe the image.", []byte("jpeg"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "A cat on a windowsill.", description)
+	assert.Equal(t, DefaultVisionModel, request.Model)
+	assert.Equal(t, []string{"anBlZw=="}, request.Images)
+}
+
+func TestEmbed_TruncatesAtRuneBoundary(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		prompt = body.Prompt
+		w.Write([]byte(`{"embedding": [0.1, 0.2]}`))
+	}))
+	defer server.Close()
+
+	// The byte limit falls inside a three-byte euro sign
+	text := "ab" + strings.Repeat("€", maxEmbedBytes/3+1)
+	_, err := New(server.URL, DefaultModel).Embed(context.Background(), text)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab"+strings.Repeat("€", (maxEmbedBytes-2)/3), prompt)
+}
@@ -0,0 +1,109 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"prabandh/pkg/resilience"
+)
+
+// ErrUnavailable is returned without contacting Ollama while the circuit
+// breaker is open after repeated failures.
+var ErrUnavailable = fmt.Errorf("ollama is unavailable: %w", resilience.ErrOpen)
+
+// APIError is a non-200 response from the Ollama API.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// Default protection for LLM calls: the breaker opens after five consecutive
+// failures for thirty seconds, the limiter allows up to four calls a second
+// and slows to one every two seconds under failure, and transient errors are
+// retried three times in total.
+const (
+	breakerThreshold = 5
+	// BreakerCooldown is how long calls fail fast once the breaker opens.
+	BreakerCooldown = 30 * time.Second
+	limiterMinRate  = 0.5
+	limiterMaxRate  = 4
+	limiterBurst    = 4
+)
+
+var defaultBackoff = resilience.Backoff{
+	Base:     500 * time.Millisecond,
+	Max:      10 * time.Second,
+	Attempts: 3,
+}
+
+// call runs fn under the client's rate limiter and circuit breaker, retrying
+// transient failures with exponential backoff and jitter. fn returns a
+// resilience.Permanent error for failures that must not be retried, such as a
+// stream that has already produced output.
+func (c *Client) call(ctx context.Context, fn func() error) error {
+	return resilience.Retry(ctx, c.Backoff, func() error {
+		if err := c.Breaker.Allow(); err != nil {
+			return resilience.Permanent(ErrUnavailable)
+		}
+		if err := c.Limiter.Wait(ctx); err != nil {
+			c.Breaker.Release()
+			return resilience.Permanent(err)
+		}
+
+		err := fn()
+		switch {
+		case err == nil:
+			c.Breaker.Success()
+			c.Limiter.Increase()
+			return nil
+		case errors.Is(ctx.Err(), context.Canceled):
+			c.Breaker.Release()
+			return resilience.Permanent(err)
+		case transient(err):
+			c.Breaker.Failure()
+			c.Limiter.Decrease()
+			return err
+		default:
+			// The server answered, it just rejected this request
+			c.Breaker.Success()
+			return resilience.Permanent(err)
+		}
+	})
+}
+
+// transient reports whether err means Ollama is down or overloaded, rather
+// than that it rejected this particular request.
+func transient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) ||
+		errors.Is(err, ErrIncompleteStream) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// Health reports the state of the client's circuit breaker and rate limiter.
+type Health struct {
+	Breaker string  `json:"breaker"`
+	Rate    float64 `json:"rate_per_second"`
+}
+
+func (c *Client) Health() Health {
+	return Health{
+		Breaker: c.Breaker.State().String(),
+		Rate:    c.Limiter.Rate(),
+	}
+}
@@ -0,0 +1,225 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"prabandh/llm/generation"
+	"prabandh/pkg/resilience"
+)
+
+// Sampling temperatures for the prompts this package sends.
+const (
+	keywordTemperature = 0.3
+	answerTemperature  = 0.2
+	captionTemperature = 0.2
+	jsonTemperature    = 0
+)
+
+// options are per-request generation settings.
+type options struct {
+	temperature float64
+	format      string   // "json" constrains the output to valid JSON
+	images      []string // Base64-encoded images for a multimodal model
+}
+
+// ErrIncompleteStream is returned when a response stream ends without its final chunk.
+var ErrIncompleteStream = errors.New("stream ended before completion")
+
+// chunk is one line of a streamed /api/generate response.
+type chunk struct {
+	generation.Stats
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error"`
+}
+
+// Event is a value sent by GenerateChan: a chunk of generated text, or the
+// final Stats, or an error. The channel is closed after Stats or Err is sent.
+type Event struct {
+	Token string
+	Stats *generation.Stats
+	Err   error
+}
+
+// GenerateStream sends prompt to the client's Model and streams the response,
+// calling onToken with each chunk as it arrives. Generation stops when ctx is
+// cancelled or onToken returns an error. On success the final Stats are returned.
+func (c *Client) GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (*generation.Stats, error) {
+	return c.generate(ctx, prompt, options{temperature: answerTemperature}, onToken)
+}
+
+// GenerateChan is GenerateStream exposed as a channel. Cancel ctx to stop
+// generation early; the channel is closed once the request has finished.
+func (c *Client) GenerateChan(ctx context.Context, prompt string) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		stats, err := c.generate(ctx, prompt, options{temperature: answerTemperature}, func(token string) error {
+			select {
+			case events <- Event{Token: token}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		final := Event{Stats: stats, Err: err}
+		select {
+		case events <- final:
+		case <-ctx.Done():
+		}
+	}()
+
+	return events
+}
+
+// Complete streams the response to prompt and returns it in full.
+func (c *Client) Complete(ctx context.Context, prompt string, temperature float64) (string, *generation.Stats, error) {
+	var response strings.Builder
+	stats, err := c.generate(ctx, prompt, options{temperature: temperature}, func(token string) error {
+		response.WriteString(token)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return response.String(), stats, nil
+}
+
+// CompleteJSON runs prompt in Ollama's JSON mode and decodes the response into
+// out. The call is bounded by the client's Timeout. The returned Stats name
+// the model that answered, which is a fallback model if Model failed.
+func (c *Client) CompleteJSON(ctx context.Context, prompt string, out interface{}) (*generation.Stats, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var response strings.Builder
+	stats, err := c.generate(ctx, prompt, options{temperature: jsonTemperature, format: "json"}, func(token string) error {
+		response.WriteString(token)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(response.String()), out); err != nil {
+		return nil, fmt.Errorf("decode model output: %w", err)
+	}
+	return stats, nil
+}
+
+// generate streams a generation from the client's Model, falling back to each
+// of its Fallbacks in turn when a model fails before producing any output.
+// Once tokens have been passed to onToken, a failure is returned as is, since
+// switching models mid-answer would mix two responses.
+func (c *Client) generate(ctx context.Context, prompt string, opts options, onToken func(string) error) (*generation.Stats, error) {
+	var failures []error
+	for _, model := range c.models() {
+		streamed := false
+		var callbackErr error
+		var stats *generation.Stats
+		err := c.call(ctx, func() error {
+			var err error
+			stats, err = c.generateWith(ctx, model, prompt, opts, func(token string) error {
+				streamed = true
+				callbackErr = onToken(token)
+				return callbackErr
+			})
+			if err != nil && streamed {
+				return resilience.Permanent(err)
+			}
+			return err
+		})
+		if err == nil || streamed || callbackErr != nil || ctx.Err() != nil || errors.Is(err, ErrUnavailable) {
+			return stats, err
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", model, err))
+	}
+	if len(failures) == 1 {
+		return nil, failures[0]
+	}
+	return nil, fmt.Errorf("all models failed: %w", errors.Join(failures...))
+}
+
+// generateWith posts a streaming /api/generate request for model and reads the
+// newline-delimited JSON chunks of the response until the final one.
+func (c *Client) generateWith(ctx context.Context, model, prompt string, opts options, onToken func(string) error) (*generation.Stats, error) {
+	requestBody := map[string]interface{}{
+		"model":  model,
+		"prompt": prompt,
+		"stream": true,
+		"options": map[string]interface{}{
+			"temperature": opts.temperature,
+		},
+	}
+	if opts.format != "" {
+		requestBody["format"] = opts.format
+	}
+	if len(opts.images) > 0 {
+		requestBody["images"] = opts.images
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// No client timeout: a stream may legitimately run for minutes, so
+	// callers bound it through ctx instead
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var c chunk
+		if err := decoder.Decode(&c); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err == io.EOF {
+				return nil, ErrIncompleteStream
+			}
+			return nil, fmt.Errorf("decode error: %w", err)
+		}
+
+		if c.Error != "" {
+			return nil, fmt.Errorf("model error: %s", c.Error)
+		}
+
+		if c.Response != "" {
+			if err := onToken(c.Response); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Done {
+			if c.Model == "" {
+				c.Model = model
+			}
+			return &c.Stats, nil
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamServer writes each part of a response as a separate flushed write,
+// so JSON chunks can be split across reads.
+func streamServer(t *testing.T, parts ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, part := range parts {
+			w.Write([]byte(part))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// blockingServer sends one token and then holds the stream open until the
+// client goes away.
+func blockingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response": "Hello", "done": false}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(url string) *Client {
+	client := New(url, DefaultModel)
+	client.Backoff.Attempts = 1
+	return client
+}
+
+func TestGenerateStream_ChunksAndStats(t *testing.T) {
+	server := streamServer(t,
+		`{"response": "Hel`, `lo", "done": false}`+"\n",
+		`{"response": " world", "done": false}`+"\n"+`{"model": "llama3.2", "done": true, `,
+		`"prompt_eval_count": 7, "eval_count": 20, "eval_duration": 2000000000, "total_duration": 3000000000}`+"\n",
+	)
+
+	var tokens []string
+	stats, err := newTestClient(server.URL).GenerateStream(context.Background(), "Say hello", func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Hello", " world"}, tokens)
+	require.NotNil(t, stats)
+	assert.Equal(t, "llama3.2", stats.Model)
+	assert.Equal(t, 7, stats.PromptEvalCount)
+	assert.Equal(t, 20, stats.EvalCount)
+	assert.Equal(t, 2*time.Second, stats.EvalDuration)
+	assert.Equal(t, 3*time.Second, stats.TotalDuration)
+	assert.InDelta(t, 10.0, stats.TokensPerSecond(), 0.001)
+}
+
+func TestGenerateStream_StatsNameRequestedModel(t *testing.T) {
+	server := streamServer(t, `{"response": "ok", "done": true}`+"\n")
+
+	stats, err := newTestClient(server.URL).GenerateStream(context.Background(), "prompt", func(string) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, DefaultModel, stats.Model, "a final chunk without a model should be attributed to the requested one")
+}
+
+func TestGenerateStream_IncompleteStream(t *testing.T) {
+	server := streamServer(t, `{"response": "Hello", "done": false}`+"\n")
+
+	stats, err := newTestClient(server.URL).GenerateStream(context.Background(), "prompt", func(string) error { return nil })
+	assert.ErrorIs(t, err, ErrIncompleteStream)
+	assert.Nil(t, stats)
+}
+
+func TestGenerateStream_CancelledMidStream(t *testing.T) {
+	server := blockingServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var tokens []string
+	_, err := newTestClient(server.URL).GenerateStream(ctx, "prompt", func(token string) error {
+		tokens = append(tokens, token)
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"Hello"}, tokens)
+}
+
+func TestGenerateStream_CallbackError(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"response": "Hello", "done": false}` + "\n" + `{"response": " world", "done": true}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.Fallbacks = []string{"fallback-model"}
+
+	errStop := errors.New("client went away")
+	calls := 0
+	_, err := client.GenerateStream(context.Background(), "prompt", func(string) error {
+		calls++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls, "generation should stop at the first failed callback")
+	assert.Equal(t, int32(1), requests.Load(), "a failed callback should not fall back to another model")
+}
+
+func TestGenerateChan_ClosesAfterStats(t *testing.T) {
+	server := streamServer(t,
+		`{"response": "Hello", "done": false}`+"\n",
+		`{"response": "", "done": true, "eval_count": 1}`+"\n",
+	)
+
+	var events []Event
+	for event := range newTestClient(server.URL).GenerateChan(context.Background(), "prompt") {
+		events = append(events, event)
+	}
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "Hello", events[0].Token)
+	assert.NoError(t, events[1].Err)
+	require.NotNil(t, events[1].Stats)
+	assert.Equal(t, 1, events[1].Stats.EvalCount)
+}
+
+func TestGenerateChan_ClosesOnError(t *testing.T) {
+	server := streamServer(t, `{"response": "Hello", "done": false}`+"\n")
+
+	var last Event
+	for event := range newTestClient(server.URL).GenerateChan(context.Background(), "prompt") {
+		last = event
+	}
+	assert.ErrorIs(t, last.Err, ErrIncompleteStream)
+}
+
+func TestGenerateChan_ClosesWhenCancelled(t *testing.T) {
+	server := blockingServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := newTestClient(server.URL).GenerateChan(ctx, "prompt")
+
+	first := <-events
+	assert.Equal(t, "Hello", first.Token)
+	cancel()
+
+	// Any final event may be dropped once ctx is done, but the channel must close
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel was not closed after cancellation")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package ollama
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// DescribeImage sends prompt with an encoded image (e.g. a JPEG) to the
+// client's VisionModel and returns the description it generates. There are
+// no fallbacks, since the generation models may not read images. The call is
+// bounded by the client's Timeout.
+func (c *Client) DescribeImage(ctx context.Context, prompt string, image []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	opts := options{
+		temperature: captionTemperature,
+		images:      []string{base64.StdEncoding.EncodeToString(image)},
+	}
+
+	var response strings.Builder
+	err := c.call(ctx, func() error {
+		// A retried generation starts over
+		response.Reset()
+		_, err := c.generateWith(ctx, c.VisionModel, prompt, opts, func(token string) error {
+			response.WriteString(token)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	description := strings.TrimSpace(response.String())
+	if description == "" {
+		return "", errors.New("empty image description")
+	}
+	return description, nil
+}
@@ -0,0 +1,250 @@
+package prompt
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"prabandh/models"
+
+	"gorm.io/gorm"
+)
+
+// Keywords is the name of the keyword extraction prompt. Overrides are
+// looked up as "keywords.<ext>" (e.g. keywords.pdf.tmpl) and by the name set
+// in IndexDir.PromptTemplate.
+const Keywords = "keywords"
+
+// Classify is the name of the prompt that assigns a file to a taxonomy category.
+const Classify = "classify"
+
+// Entities is the name of the prompt that extracts named entities as JSON.
+const Entities = "entities"
+
+// Translate is the name of the prompt that translates keywords into the
+// common search language.
+const Translate = "translate"
+
+// Caption is the name of the prompt sent with an image to the vision model.
+const Caption = "caption"
+
+// MaxContentLength bounds how much file content is placed in a prompt.
+const MaxContentLength = 10000
+
+//go:embed defaults/*.tmpl
+var defaults embed.FS
+
+// versionComment matches a {{/* version: X */}} declaration in a template.
+var versionComment = regexp.MustCompile(`\{\{-?\s*/\*\s*version:\s*([^\s*]+)\s*\*/\s*-?\}\}`)
+
+// Template is a parsed prompt template.
+type Template struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+// ID identifies the template and its version, e.g. "keywords@2". It is stored
+// alongside generated keywords and used as the LLM cache key, so changing a
+// template invalidates what the old one produced.
+func (t *Template) ID() string {
+	return t.Name + "@" + t.Version
+}
+
+// Data is what a prompt template is rendered with.
+type Data struct {
+	FileName  string
+	FilePath  string
+	Extension string
+	Size      int64
+	Created   time.Time
+	Modified  time.Time
+	Content   string
+
+	// Candidates are statistically extracted keywords offered to the
+	// Keywords prompt to choose from, when keywords are prefiltered
+	Candidates []string
+
+	// Categories are the choices offered by the Classify prompt
+	Categories []Category
+
+	// Entities are the dates and amounts already found by pattern matching,
+	// offered to the Entities prompt
+	Entities []Entity
+
+	// Keywords are translated by the Translate prompt from Language into
+	// TargetLanguage, both given by name
+	Keywords       []string
+	Language       string
+	TargetLanguage string
+}
+
+// Category is a taxonomy category offered to the Classify prompt.
+type Category struct {
+	Path        string
+	Description string
+}
+
+// Entity is a named entity offered to the Entities prompt.
+type Entity struct {
+	Type  string
+	Value string
+}
+
+// NewData describes file for a prompt, truncating content to MaxContentLength.
+func NewData(file models.FileIndex, content string) Data {
+	if len(content) > MaxContentLength {
+		content = strings.ToValidUTF8(content[:MaxContentLength], "")
+	}
+	return Data{
+		FileName:  file.FileName,
+		FilePath:  file.FilePath,
+		Extension: file.Extension,
+		Size:      file.Size,
+		Created:   file.CreatedDate,
+		Modified:  file.ModifiedDate,
+		Content:   content,
+	}
+}
+
+// Render executes the template with data.
+func (t *Template) Render(data Data) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", t.ID(), err)
+	}
+	return b.String(), nil
+}
+
+// funcs are available to every template.
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// parse builds a Template from source. The version is taken from a
+// {{/* version: X */}} comment, or derived from the text when there is none.
+func parse(name, source string) (*Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt %s: %w", name, err)
+	}
+
+	version := ""
+	if m := versionComment.FindStringSubmatch(source); m != nil {
+		version = m[1]
+	} else {
+		sum := sha256.Sum256([]byte(source))
+		version = "sha-" + hex.EncodeToString(sum[:6])
+	}
+
+	return &Template{Name: name, Version: version, tmpl: tmpl}, nil
+}
+
+// Library holds the built-in prompts overlaid with any from a config directory.
+type Library struct {
+	templates map[string]*Template
+}
+
+// Load reads the built-in prompts and then every *.tmpl file in dir, which
+// replace built-ins of the same name. An empty dir loads the built-ins only.
+func Load(dir string) (*Library, error) {
+	l := &Library{templates: make(map[string]*Template)}
+
+	err := fs.WalkDir(defaults, "defaults", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		source, err := defaults.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return l.add(path, string(source))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return l, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.add(path, string(source)); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// LoadFromEnv loads prompts with overrides from the PROMPT_DIR directory.
+func LoadFromEnv() (*Library, error) {
+	return Load(os.Getenv("PROMPT_DIR"))
+}
+
+func (l *Library) add(path, source string) error {
+	name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+	t, err := parse(name, source)
+	if err != nil {
+		return err
+	}
+	l.templates[name] = t
+	return nil
+}
+
+// Get returns the template called name.
+func (l *Library) Get(name string) (*Template, bool) {
+	t, ok := l.templates[name]
+	return t, ok
+}
+
+// ForFile picks the keyword prompt for file: the template named by dir (the
+// most specific index directory containing the file, may be nil), then one for
+// the file's extension, then the default.
+func (l *Library) ForFile(file models.FileIndex, dir *models.IndexDir) *Template {
+	if dir != nil && dir.PromptTemplate != "" {
+		if t, ok := l.templates[strings.TrimSuffix(dir.PromptTemplate, ".tmpl")]; ok {
+			return t
+		}
+	}
+
+	if ext := strings.ToLower(strings.TrimPrefix(file.Extension, ".")); ext != "" {
+		if t, ok := l.templates[Keywords+"."+ext]; ok {
+			return t
+		}
+	}
+
+	return l.templates[Keywords]
+}
+
+// IndexDirFor returns the most specific index directory that contains path
+// and sets a prompt template, or nil if there is none.
+func IndexDirFor(db *gorm.DB, path string) (*models.IndexDir, error) {
+	var dir models.IndexDir
+	err := db.Where("prompt_template <> '' AND (directory_location = ? OR left(?, length(directory_location) + 1) = directory_location || '/')", path, path).
+		Order("length(directory_location) DESC").
+		First(&dir).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dir, nil
+}
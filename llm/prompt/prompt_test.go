@@ -0,0 +1,191 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"prabandh/models"
+)
+
+func TestDefaultKeywordsPrompt(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tmpl := lib.ForFile(models.FileIndex{Extension: ".txt"}, nil)
+	if tmpl.ID() != "keywords@2" {
+		t.Errorf("Expected keywords@2, got %s", tmpl.ID())
+	}
+
+	file := models.FileIndex{
+		FileName:     "contract.txt",
+		FilePath:     "/docs/contract.txt",
+		Size:         42,
+		ModifiedDate: time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC),
+	}
+	rendered, err := tmpl.Render(NewData(file, "Payment is due within 30 days."))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"File: contract.txt\nPath: /docs/contract.txt\nSize: 42 bytes\nModified: 2024-07-01T09:30:00Z\n",
+		"Content:\nPayment is due within 30 days.",
+		"- academics\n- module1\n",
+		"- os\n\nFile: contract.txt",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Rendered prompt missing %q:\n%s", want, rendered)
+		}
+	}
+	if strings.HasPrefix(rendered, "\n") || strings.Contains(rendered, "Created:") {
+		t.Errorf("Unexpected output around comments or zero dates:\n%s", rendered)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, source string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("keywords.pdf.tmpl", "{{/* version: pdf-1 */}}PDF {{.FileName}}")
+	write("legal.tmpl", "Legal {{.FileName}}")
+
+	lib, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	pdf := models.FileIndex{FileName: "a.PDF", Extension: ".PDF"}
+	txt := models.FileIndex{FileName: "a.txt", Extension: ".txt"}
+	legal := &models.IndexDir{PromptTemplate: "legal"}
+
+	t.Run("Extension override", func(t *testing.T) {
+		if got := lib.ForFile(pdf, nil).ID(); got != "keywords.pdf@pdf-1" {
+			t.Errorf("Expected keywords.pdf@pdf-1, got %s", got)
+		}
+	})
+
+	t.Run("Index directory override wins", func(t *testing.T) {
+		tmpl := lib.ForFile(pdf, legal)
+		if tmpl.Name != "legal" || !strings.HasPrefix(tmpl.Version, "sha-") {
+			t.Errorf("Expected legal with a content version, got %s", tmpl.ID())
+		}
+	})
+
+	t.Run("Unknown directory template falls back", func(t *testing.T) {
+		if got := lib.ForFile(txt, &models.IndexDir{PromptTemplate: "missing"}).Name; got != Keywords {
+			t.Errorf("Expected default template, got %s", got)
+		}
+	})
+}
+
+func TestVersionChangesWithText(t *testing.T) {
+	a, err := parse("x", "Keywords for {{.FileName}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := parse("x", "Tags for {{.FileName}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Version == b.Version {
+		t.Errorf("Expected different versions for different text, both %s", a.Version)
+	}
+}
+
+func TestClassifyPromptListsCategories(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tmpl, ok := lib.Get(Classify)
+	if !ok {
+		t.Fatal("Expected a built-in classify prompt")
+	}
+
+	data := NewData(models.FileIndex{FileName: "q3.pdf", FilePath: "/docs/q3.pdf"}, "Invoice total")
+	data.Categories = []Category{{Path: "finance/invoices", Description: "bills from vendors"}, {Path: "finance/tax"}}
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if !strings.Contains(rendered, "Categories:\n- finance/invoices: bills from vendors\n- finance/tax\n\nFile: q3.pdf") {
+		t.Errorf("Unexpected category list:\n%s", rendered)
+	}
+}
+
+func TestKeywordsPromptOffersCandidates(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	data := NewData(models.FileIndex{FileName: "contract.txt"}, "Payment is due within 30 days.")
+	data.Candidates = []string{"payment terms", "due date"}
+	rendered, err := lib.ForFile(models.FileIndex{}, nil).Render(data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if !strings.Contains(rendered, "- os\n\nCandidate keywords found by word statistics, to choose from or improve on: payment terms, due date\n\nFile: contract.txt") {
+		t.Errorf("Expected the candidates before the file metadata:\n%s", rendered)
+	}
+}
+
+func TestTranslatePrompt(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tmpl, ok := lib.Get(Translate)
+	if !ok {
+		t.Fatal("Expected a built-in translate prompt")
+	}
+
+	data := NewData(models.FileIndex{FileName: "rechnung.pdf"}, "")
+	data.Keywords = []string{"rechnung", "mehrwertsteuer"}
+	data.Language, data.TargetLanguage = "German", "English"
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, want := range []string{"from German to English", "Keywords:\n- rechnung\n- mehrwertsteuer"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Rendered prompt missing %q:\n%s", want, rendered)
+		}
+	}
+}
+
+func TestCaptionPrompt(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tmpl, ok := lib.Get(Caption)
+	if !ok {
+		t.Fatal("Expected a built-in caption prompt")
+	}
+
+	rendered, err := tmpl.Render(NewData(models.FileIndex{FileName: "IMG_0042.jpg", FilePath: "/photos/IMG_0042.jpg"}, ""))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(rendered, "File: IMG_0042.jpg\nPath: /photos/IMG_0042.jpg") {
+		t.Errorf("Rendered prompt missing the file:\n%s", rendered)
+	}
+	if strings.Contains(rendered, "Content:") {
+		t.Errorf("Caption prompt should not carry content:\n%s", rendered)
+	}
+}
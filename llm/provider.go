@@ -0,0 +1,63 @@
+// Package llm selects the language model backend used for keywords,
+// classification, entity extraction, answers, image captions and embeddings.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"prabandh/llm/fake"
+	"prabandh/llm/generation"
+	"prabandh/llm/ollama"
+)
+
+// Provider is a language model backend. Prompts are rendered by the caller
+// (see package prompt).
+type Provider interface {
+	// ModelName identifies the generation model; cached output is keyed by it
+	ModelName() string
+	// EmbedModelName identifies the model behind Embed
+	EmbedModelName() string
+	// VisionModelName identifies the model behind DescribeImage
+	VisionModelName() string
+
+	// ExtractKeywords answers a keyword prompt with a list of keywords; the
+	// stats name the model that answered, which may be a fallback model
+	ExtractKeywords(ctx context.Context, prompt string) ([]string, *generation.Stats, error)
+	// CompleteJSON answers prompt with JSON decoded into out; the stats name
+	// the model that answered
+	CompleteJSON(ctx context.Context, prompt string, out interface{}) (*generation.Stats, error)
+	// GenerateStream answers prompt, calling onToken as the text is generated
+	GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (*generation.Stats, error)
+	// DescribeImage answers prompt about an encoded image
+	DescribeImage(ctx context.Context, prompt string, image []byte) (string, error)
+	// Embed returns a models.EmbeddingDimensions-sized vector for text
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+var (
+	_ Provider = (*ollama.Client)(nil)
+	_ Provider = (*fake.Provider)(nil)
+)
+
+// Provider names accepted in LLM_PROVIDER.
+const (
+	ProviderOllama = "ollama"
+	ProviderFake   = "fake"
+)
+
+// NewFromEnv returns the provider named by LLM_PROVIDER: "ollama" (the
+// default), configured by ollama.NewFromEnv, or "fake", which needs no model
+// server and derives keywords and embeddings from the text itself.
+func NewFromEnv() (Provider, error) {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER"))); name {
+	case "", ProviderOllama:
+		return ollama.NewFromEnv(), nil
+	case ProviderFake:
+		return fake.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q (use %s or %s)", name, ProviderOllama, ProviderFake)
+	}
+}
@@ -0,0 +1,62 @@
+package tagger
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"prabandh/pkg/langdetect"
+)
+
+// Mode selects how keywords are generated.
+type Mode string
+
+const (
+	// ModeLLM asks the model for keywords
+	ModeLLM Mode = "llm"
+	// ModeStatistical uses keywords.Extractor alone, without a model
+	ModeStatistical Mode = "statistical"
+	// ModeFallback asks the model and uses keywords.Extractor when it fails.
+	// Files tagged by the fallback are stale for Regenerate, so they are
+	// retagged by the model once it is back.
+	ModeFallback Mode = "fallback"
+	// ModePrefilter offers keywords.Extractor's candidates to the model to
+	// choose from and refine
+	ModePrefilter Mode = "prefilter"
+)
+
+// ParseMode validates a mode name; an empty name is ModeLLM.
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return ModeLLM, nil
+	case ModeLLM, ModeStatistical, ModeFallback, ModePrefilter:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown keyword mode %q (use %s, %s, %s or %s)", name, ModeLLM, ModeStatistical, ModeFallback, ModePrefilter)
+}
+
+// Config configures a Tagger.
+type Config struct {
+	Mode Mode
+	// Language is the ISO 639-1 code of a common search language. When set,
+	// keywords of files detected to be in another language are also
+	// translated into it, so one query finds files in every language.
+	Language string
+}
+
+// ConfigFromEnv reads the mode from KEYWORD_MODE and the search language
+// from KEYWORD_LANGUAGE, which is empty to keep keywords untranslated.
+func ConfigFromEnv() (Config, error) {
+	mode, err := ParseMode(os.Getenv("KEYWORD_MODE"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	language := strings.ToLower(strings.TrimSpace(os.Getenv("KEYWORD_LANGUAGE")))
+	if language != "" && !slices.Contains(langdetect.Codes(), language) {
+		return Config{}, fmt.Errorf("unsupported KEYWORD_LANGUAGE %q (use one of %s)", language, strings.Join(langdetect.Codes(), ", "))
+	}
+	return Config{Mode: mode, Language: language}, nil
+}
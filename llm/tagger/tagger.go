@@ -0,0 +1,200 @@
+package tagger
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"prabandh/keywords"
+	"prabandh/llm"
+	"prabandh/llm/cache"
+	"prabandh/llm/prompt"
+	"prabandh/models"
+
+	"gorm.io/gorm"
+)
+
+// maxKeywordLength drops runaway "keywords" that are really sentences.
+const maxKeywordLength = 50
+
+const (
+	// statisticalKeywords is how many keywords the statistical extractor returns
+	statisticalKeywords = 10
+	// prefilterCandidates is how many statistical candidates the model is offered
+	prefilterCandidates = 20
+)
+
+// Result is the outcome of tagging a file.
+type Result struct {
+	Keywords []string
+	// PromptVersion is the ID of the prompt template that produced the
+	// keywords, or keywords.ExtractorVersion for statistical keywords
+	PromptVersion string
+	Cached        bool
+}
+
+// Tagger generates keywords for file content, with the LLM or statistically
+// depending on its Mode, and translates them into the configured language.
+// LLM keywords use the prompt template configured for the file, and cached
+// keywords are reused for content already seen with the same model and
+// template.
+type Tagger struct {
+	db        *gorm.DB
+	provider  llm.Provider
+	prompts   *prompt.Library
+	cache     *cache.Cache
+	extractor *keywords.Extractor
+	config    Config
+}
+
+func New(db *gorm.DB, provider llm.Provider, prompts *prompt.Library, config Config) *Tagger {
+	return &Tagger{
+		db:        db,
+		provider:  provider,
+		prompts:   prompts,
+		cache:     cache.New(db),
+		extractor: keywords.NewExtractor(db),
+		config:    config,
+	}
+}
+
+// Template returns the keyword prompt template that applies to file.
+func (t *Tagger) Template(ctx context.Context, file models.FileIndex) (*prompt.Template, error) {
+	dir, err := prompt.IndexDirFor(t.db.WithContext(ctx), file.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return t.prompts.ForFile(file, dir), nil
+}
+
+// Version returns the prompt version that tagging file succeeds with in the
+// tagger's mode, which Regenerate compares stored keywords against.
+func (t *Tagger) Version(ctx context.Context, file models.FileIndex) (string, error) {
+	if t.config.Mode == ModeStatistical {
+		return keywords.ExtractorVersion, nil
+	}
+
+	tmpl, err := t.Template(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	if t.config.Mode == ModePrefilter {
+		return prefilterVersion(tmpl), nil
+	}
+	return tmpl.ID(), nil
+}
+
+// Tag returns keywords for file's content. hash identifies the content for
+// caching; an empty or "error-hash" hash disables the cache.
+func (t *Tagger) Tag(ctx context.Context, file models.FileIndex, hash, content string) (*Result, error) {
+	result, err := t.tag(ctx, file, hash, content)
+	if err != nil || t.config.Language == "" || file.Language == "" || file.Language == t.config.Language {
+		return result, err
+	}
+	return t.translate(ctx, file, hash, result), nil
+}
+
+func (t *Tagger) tag(ctx context.Context, file models.FileIndex, hash, content string) (*Result, error) {
+	switch t.config.Mode {
+	case ModeStatistical:
+		return t.Statistical(ctx, content)
+
+	case ModePrefilter:
+		candidates, err := t.extractor.WithContext(ctx).Extract(content, prefilterCandidates)
+		if err != nil {
+			return nil, err
+		}
+		return t.generate(ctx, file, hash, content, candidates)
+
+	case ModeFallback:
+		result, err := t.generate(ctx, file, hash, content, nil)
+		if err == nil || ctx.Err() != nil {
+			return result, err
+		}
+		if fallback, statErr := t.Statistical(ctx, content); statErr == nil {
+			return fallback, nil
+		}
+		return nil, err
+	}
+
+	return t.generate(ctx, file, hash, content, nil)
+}
+
+// generate asks the model for keywords, offering it candidates if any.
+func (t *Tagger) generate(ctx context.Context, file models.FileIndex, hash, content string, candidates []string) (*Result, error) {
+	tmpl, err := t.Template(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	version := tmpl.ID()
+	if candidates != nil {
+		version = prefilterVersion(tmpl)
+	}
+
+	cacheable := hash != "" && hash != "error-hash"
+	model := t.provider.ModelName()
+	keywordCache := t.cache.WithContext(ctx)
+
+	if cacheable {
+		// A failed lookup only costs an LLM call
+		if cached, ok, err := keywordCache.Get(hash, model, version); err == nil && ok {
+			return &Result{Keywords: cached, PromptVersion: version, Cached: true}, nil
+		}
+	}
+
+	data := prompt.NewData(file, content)
+	data.Candidates = candidates
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, stats, err := t.provider.ExtractKeywords(ctx, rendered)
+	if err != nil {
+		return nil, err
+	}
+	tags := clean(raw)
+
+	if cacheable {
+		// Keywords from a fallback model are cached under its name, so the
+		// primary model is asked again rather than served them from the cache
+		if stats != nil && stats.Model != "" {
+			model = stats.Model
+		}
+		// The keywords are usable even if they could not be cached
+		_ = keywordCache.Put(hash, model, version, tags)
+	}
+	return &Result{Keywords: tags, PromptVersion: version}, nil
+}
+
+// Statistical extracts keywords without the model. They are not cached, as
+// they depend on the rest of the corpus.
+func (t *Tagger) Statistical(ctx context.Context, content string) (*Result, error) {
+	extracted, err := t.extractor.WithContext(ctx).Extract(content, statisticalKeywords)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Keywords: clean(extracted), PromptVersion: keywords.ExtractorVersion}, nil
+}
+
+// prefilterVersion identifies keywords refined by tmpl from statistical candidates.
+func prefilterVersion(tmpl *prompt.Template) string {
+	return tmpl.ID() + "+" + keywords.ExtractorVersion
+}
+
+// clean trims and de-duplicates keywords, dropping ones too short or long to be useful.
+func clean(raw []string) []string {
+	seen := make(map[string]bool, len(raw))
+	keywords := make([]string, 0, len(raw))
+	for _, kw := range raw {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		kw = strings.Trim(kw, `.,;:"'!?`)
+		if n := utf8.RuneCountInString(kw); n <= 2 || n >= maxKeywordLength || seen[kw] {
+			continue
+		}
+		seen[kw] = true
+		keywords = append(keywords, kw)
+	}
+	return keywords
+}
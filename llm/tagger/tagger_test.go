@@ -0,0 +1,161 @@
+package tagger
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"prabandh/keywords"
+	"prabandh/llm/fake"
+	"prabandh/llm/generation"
+	"prabandh/llm/prompt"
+	"prabandh/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// countingProvider records keyword requests and can report a different
+// model as having answered them, as a fallback would.
+type countingProvider struct {
+	*fake.Provider
+	calls      int
+	answeredBy string
+}
+
+func (p *countingProvider) ExtractKeywords(ctx context.Context, prompt string) ([]string, *generation.Stats, error) {
+	p.calls++
+	keywords, stats, err := p.Provider.ExtractKeywords(ctx, prompt)
+	if stats != nil && p.answeredBy != "" {
+		stats.Model = p.answeredBy
+	}
+	return keywords, stats, err
+}
+
+var (
+	dirQuery   = regexp.QuoteMeta(`SELECT * FROM "index_dirs"`)
+	cacheQuery = regexp.QuoteMeta(`SELECT * FROM "keyword_caches" WHERE (hash = $1 AND model_name = $2 AND prompt_version = $3)`)
+	cacheHit   = regexp.QuoteMeta(`UPDATE "keyword_caches" SET "hits"=hits + 1`)
+	cachePut   = regexp.QuoteMeta(`INSERT INTO "keyword_caches"`)
+)
+
+func newTestTagger(t *testing.T) (*Tagger, *countingProvider, sqlmock.Sqlmock, string) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		sqlDB.Close()
+	})
+
+	prompts, err := prompt.Load("")
+	require.NoError(t, err)
+	provider := &countingProvider{Provider: fake.New()}
+	version := prompts.ForFile(testFile, nil).ID()
+	return New(db, provider, prompts, Config{Mode: ModeLLM}), provider, mock, version
+}
+
+var testFile = models.FileIndex{FilePath: "/docs/invoice.txt", FileName: "invoice.txt", Extension: ".txt"}
+
+const testContent = "The quarterly invoice lists consulting fees, travel expenses and the tax owed."
+
+func TestTag_CacheHitSkipsProvider(t *testing.T) {
+	tagger, provider, mock, version := newTestTagger(t)
+
+	mock.ExpectQuery(dirQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(cacheQuery).
+		WithArgs("abc", fake.Model, version, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "keywords"}).AddRow(3, "invoice\ntax"))
+	mock.ExpectBegin()
+	mock.ExpectExec(cacheHit).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := tagger.Tag(context.Background(), testFile, "abc", testContent)
+	require.NoError(t, err)
+	assert.True(t, result.Cached)
+	assert.Equal(t, []string{"invoice", "tax"}, result.Keywords)
+	assert.Equal(t, version, result.PromptVersion)
+	assert.Zero(t, provider.calls)
+}
+
+func TestTag_CacheMissCallsProviderAndStores(t *testing.T) {
+	tagger, provider, mock, version := newTestTagger(t)
+
+	mock.ExpectQuery(dirQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(cacheQuery).
+		WithArgs("abc", fake.Model, version, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(cachePut).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "abc", fake.Model, version, sqlmock.AnyArg(), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hits"}).AddRow(1, 0))
+	mock.ExpectCommit()
+
+	result, err := tagger.Tag(context.Background(), testFile, "abc", testContent)
+	require.NoError(t, err)
+	assert.False(t, result.Cached)
+	assert.NotEmpty(t, result.Keywords)
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestTag_FallbackModelCachedUnderItsName(t *testing.T) {
+	tagger, provider, mock, version := newTestTagger(t)
+	provider.answeredBy = "fallback-model"
+
+	mock.ExpectQuery(dirQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(cacheQuery).
+		WithArgs("abc", fake.Model, version, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(cachePut).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "abc", "fallback-model", version, sqlmock.AnyArg(), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hits"}).AddRow(1, 0))
+	mock.ExpectCommit()
+
+	_, err := tagger.Tag(context.Background(), testFile, "abc", testContent)
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestTag_OtherPromptVersionMisses(t *testing.T) {
+	tagger, provider, mock, version := newTestTagger(t)
+	tagger.config.Mode = ModePrefilter
+	prefiltered := version + "+" + keywords.ExtractorVersion
+
+	// Keywords cached for the plain template are not served to prefilter
+	// mode, whose version differs
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "file_contents"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM unnest(`)).
+		WillReturnRows(sqlmock.NewRows([]string{"phrase", "documents"}))
+	mock.ExpectQuery(dirQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(cacheQuery).
+		WithArgs("abc", fake.Model, prefiltered, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(cachePut).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "abc", fake.Model, prefiltered, sqlmock.AnyArg(), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hits"}).AddRow(1, 0))
+	mock.ExpectCommit()
+
+	_, err := tagger.Tag(context.Background(), testFile, "abc", testContent)
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestTag_NoCacheWithoutHash(t *testing.T) {
+	tagger, provider, mock, _ := newTestTagger(t)
+
+	mock.ExpectQuery(dirQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	result, err := tagger.Tag(context.Background(), testFile, "error-hash", testContent)
+	require.NoError(t, err)
+	assert.False(t, result.Cached)
+	assert.Equal(t, 1, provider.calls)
+}
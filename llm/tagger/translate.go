@@ -0,0 +1,58 @@
+package tagger
+
+import (
+	"context"
+
+	"prabandh/llm/prompt"
+	"prabandh/models"
+	"prabandh/pkg/langdetect"
+)
+
+// translate adds translations of result's keywords into the configured
+// language, which the model is asked for in JSON mode. Translation is best
+// effort: if it fails, result is returned as it is.
+func (t *Tagger) translate(ctx context.Context, file models.FileIndex, hash string, result *Result) *Result {
+	tmpl, ok := t.prompts.Get(prompt.Translate)
+	if !ok || len(result.Keywords) == 0 {
+		return result
+	}
+
+	// The translations are cached with the keywords they were made from
+	version := result.PromptVersion + "+" + tmpl.ID() + ":" + t.config.Language
+	cacheable := hash != "" && hash != "error-hash"
+	model := t.provider.ModelName()
+	keywordCache := t.cache.WithContext(ctx)
+
+	if cacheable {
+		if cached, ok, err := keywordCache.Get(hash, model, version); err == nil && ok {
+			return &Result{Keywords: cached, PromptVersion: result.PromptVersion, Cached: result.Cached}
+		}
+	}
+
+	data := prompt.NewData(file, "")
+	data.Keywords = result.Keywords
+	data.Language = langdetect.Name(file.Language)
+	data.TargetLanguage = langdetect.Name(t.config.Language)
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		return result
+	}
+
+	var response struct {
+		Translations []string `json:"translations"`
+	}
+	stats, err := t.provider.CompleteJSON(ctx, rendered, &response)
+	if err != nil {
+		return result
+	}
+
+	tags := clean(append(append([]string{}, result.Keywords...), response.Translations...))
+	if cacheable {
+		// As in generate, a fallback model's translations are cached under its name
+		if stats != nil && stats.Model != "" {
+			model = stats.Model
+		}
+		_ = keywordCache.Put(hash, model, version, tags)
+	}
+	return &Result{Keywords: tags, PromptVersion: result.PromptVersion}
+}
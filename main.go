@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"prabandh/database"
 	"prabandh/indexer"
+	"prabandh/llm"
+	"prabandh/llm/ollama"
+	"prabandh/llm/prompt"
 	"prabandh/routers"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -17,25 +29,113 @@ func main() {
 		panic("Error loading .env file")
 	}
 
+	// Cancelled on Ctrl+C or SIGTERM so indexing and in-flight requests stop promptly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	database.Connect()
 
-	fileIndexer := indexer.FileIndexer{}
+	provider, err := llm.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure LLM provider: %v", err)
+	}
+	if client, ok := provider.(*ollama.Client); ok {
+		status, err := client.EnsureModels(ctx, ollama.PullFromEnv(), logPullProgress())
+		if err != nil {
+			log.Printf("Ollama check failed, keyword generation will not work: %v", err)
+		} else if len(status.Missing) > 0 {
+			log.Printf("Ollama models missing: %s", strings.Join(status.Missing, ", "))
+		}
+	}
+
+	prompts, err := prompt.LoadFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+
+	indexConfig, err := indexer.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure indexing: %v", err)
+	}
+
+	fileIndexer := indexer.NewFileIndexer(provider, prompts, indexConfig, false)
 	directoryPath := os.Getenv("DATA_PATH")
 	if directoryPath == "" {
 		panic("DATA_PATH is not set in the environment")
 	}
-	fileIndexer.IndexDirectory(directoryPath)
+	if err := fileIndexer.IndexDirectory(ctx, directoryPath); err != nil {
+		log.Printf("Indexing stopped: %v", err)
+		return
+	}
+
+	// Retry files whose keywords could not be generated, e.g. while Ollama was down
+	go fileIndexer.RetryPendingLoop(ctx, time.Minute)
+
+	// Detect languages of files indexed before they were recorded
+	go func() {
+		if _, err := fileIndexer.DetectLanguages(ctx); err != nil {
+			log.Printf("Language detection stopped: %v", err)
+		}
+	}()
 
 	r := gin.Default()
 
 	// Use routers
 	routers.RegisterFileRoutes(r)
 	routers.RegisterIndexDirRoutes(r)
-	routers.RegisterSummaryRoutes(r, database.DB, "http://localhost:11434") // ollama url
+	routers.RegisterDuplicateRoutes(r)
+	routers.RegisterLLMCacheRoutes(r, database.DB)
+	routers.RegisterTaxonomyRoutes(r)
+	routers.RegisterSummaryRoutes(r, database.DB, fileIndexer)
+	routers.RegisterSearchRoutes(r, database.DB, provider)
+	routers.RegisterKeywordRoutes(r, database.DB)
+	routers.RegisterAskRoutes(r, database.DB, provider, indexConfig.Redact)
+	routers.RegisterLLMRoutes(r, database.DB, provider)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080" // Default port
 	}
-	r.Run(":" + port)
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+		// Request contexts derive from ctx, so shutting down cancels
+		// in-flight LLM calls and queries
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+}
+
+// logPullProgress logs model pulls at each new status and every 10% of a download.
+func logPullProgress() func(ollama.PullProgress) {
+	var lastStatus string
+	var lastPercent float64
+	return func(p ollama.PullProgress) {
+		percent := p.Percent()
+		if p.Status == lastStatus && percent-lastPercent < 10 {
+			return
+		}
+		lastStatus, lastPercent = p.Status, percent
+
+		if percent >= 0 {
+			log.Printf("Pulling %s: %s %.0f%%", p.Model, p.Status, percent)
+		} else {
+			log.Printf("Pulling %s: %s", p.Model, p.Status)
+		}
+	}
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Category is a node of the team-defined taxonomy, identified by a
+// slash-separated path such as "finance/invoices".
+type Category struct {
+	gorm.Model
+	Path        string `gorm:"not null;uniqueIndex"`
+	Description string // Shown to the classifier to disambiguate categories
+}
+
+// FileCategory assigns a file to exactly one category.
+type FileCategory struct {
+	gorm.Model
+	FileIndexID   uint    `gorm:"not null;uniqueIndex"` // Foreign key linking to FileIndex
+	CategoryID    uint    `gorm:"not null;index"`       // Foreign key linking to Category
+	Confidence    float64 `gorm:"not null"`             // 0-1, 1 for manual assignments
+	Source        string  `gorm:"not null"`             // "llm" or "manual"
+	PromptVersion string  // Prompt template ID for LLM assignments
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Entity is a person, organisation, place, date or monetary amount
+// mentioned in a file.
+type Entity struct {
+	gorm.Model
+	FileIndexID uint       `gorm:"not null;index"` // Foreign key linking to FileIndex
+	Type        string     `gorm:"not null;index:idx_entity_value"`
+	Value       string     `gorm:"not null;index:idx_entity_value"` // Normalised, e.g. "2024-03-15" or "1250.00 EUR"
+	Text        string     `gorm:"not null"`                        // As written in the file
+	Date        *time.Time `gorm:"index"`                           // Set for dates
+	Amount      *float64   `gorm:"index"`                           // Set for amounts
+	Currency    string     // ISO 4217 code for amounts, when known
+	Source      string     `gorm:"not null"` // "pattern" or "llm"
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// FileCaption is a vision model's description of an image, stored once per
+// distinct file hash. The caption is also saved as the image's FileContent,
+// so keywords, embeddings and full-text search are derived from it.
+type FileCaption struct {
+	gorm.Model
+	Hash          string `gorm:"not null;uniqueIndex"` // SHA-256 shared with FileIndex.Hash
+	Caption       string `gorm:"not null"`
+	VisionModel   string `gorm:"not null"` // Model that wrote the caption
+	PromptVersion string `gorm:"not null"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// FileContent stores extracted text once per distinct file hash.
+// A generated content_tsv column and its GIN index are added in database.Connect.
+type FileContent struct {
+	gorm.Model
+	Hash    string `gorm:"not null;uniqueIndex"` // SHA-256 shared with FileIndex.Hash
+	Content string `gorm:"not null"`
+	Simhash *int64 // 64-bit simhash fingerprint for near-duplicate detection; nil until computed
+	// SearchConfig is the text search configuration content_tsv is built with,
+	// chosen by the content's language
+	SearchConfig string `gorm:"type:regconfig;not null;default:'english'"`
+	Source       string `gorm:"not null;default:'text'"` // How the content was extracted, one of the ContentSource constants
+}
+
+const (
+	// ContentSourceText is content read from a text file
+	ContentSourceText = "text"
+	// ContentSourceOCR is content recognised in an image or scanned PDF
+	ContentSourceOCR = "ocr"
+	// ContentSourceCaption is an image's description by a vision model
+	ContentSourceCaption = "caption"
+)
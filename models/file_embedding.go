@@ -0,0 +1,15 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// EmbeddingDimensions matches the output size of the default embedding model (nomic-embed-text).
+const EmbeddingDimensions = 768
+
+type FileEmbedding struct {
+	gorm.Model
+	FileIndexID    uint   `gorm:"not null;uniqueIndex"` // Foreign key linking to FileIndex
+	EmbeddingModel string `gorm:"not null"`             // Model that produced the vector
+	Embedding      Vector `gorm:"type:vector(768);not null"`
+}
@@ -14,5 +14,7 @@ type FileIndex struct {
 	CreatedDate  time.Time `gorm:"not null"`
 	ModifiedDate time.Time `gorm:"not null"`
 	Size         int64     `gorm:"not null"`
-	Hash         string    `gorm:"not null"`
+	Hash         string    `gorm:"not null;index"`
+	Language     string    `gorm:"index"`                        // ISO 639-1 code detected from the content, empty if unknown
+	Sensitive    bool      `gorm:"not null;default:false;index"` // Secrets or card numbers were found in the content or path
 }
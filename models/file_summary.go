@@ -8,6 +8,7 @@ type FileSummary struct {
 	gorm.Model
 	FileIndexID    uint   `gorm:"not null;index"` // Foreign key linking to FileIndex
 	SummaryKeyword string `gorm:"not null;index"` // Each keyword gets its own row
+	PromptVersion  string `gorm:"index"`          // Prompt template ID that produced the keyword
 }
 
 // Index on FileIndexID and SummaryKeyword for faster searches
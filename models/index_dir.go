@@ -8,4 +8,6 @@ type IndexDir struct {
 	gorm.Model
 	DirectoryLocation string `gorm:"not null;unique"`
 	IsWhitelisted     bool   `gorm:"default:true"` // Default to whitelisted
+	PromptTemplate    string // Keyword prompt template for files in this directory, empty for the default
+	CaptionImages     bool   `gorm:"not null;default:false"` // Describe images with the vision model, which is slow
 }
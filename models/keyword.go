@@ -0,0 +1,18 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Keyword is a canonical tag shared by any number of files.
+type Keyword struct {
+	gorm.Model
+	Name string `gorm:"not null"`             // Display form, e.g. "machine learning"
+	Key  string `gorm:"not null;uniqueIndex"` // Normalised, stemmed form used for matching
+}
+
+// FileKeyword links a file to a canonical keyword.
+type FileKeyword struct {
+	FileIndexID uint `gorm:"primaryKey"`
+	KeywordID   uint `gorm:"primaryKey;index"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// KeywordCache stores keywords generated for a piece of content so identical
+// content is not sent to the LLM twice.
+type KeywordCache struct {
+	gorm.Model
+	Hash          string `gorm:"not null;uniqueIndex:idx_keyword_cache_key"` // SHA-256 of the content
+	ModelName     string `gorm:"not null;uniqueIndex:idx_keyword_cache_key"` // LLM that generated the keywords
+	PromptVersion string `gorm:"not null;uniqueIndex:idx_keyword_cache_key"` // Version of the prompt used
+	Keywords      string `gorm:"not null"`                                   // Newline-separated keywords
+	Hits          int64  `gorm:"not null;default:0"`                         // Times the entry has been reused
+}
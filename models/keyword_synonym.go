@@ -0,0 +1,12 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// KeywordSynonym maps an alias such as "ml" onto a canonical keyword.
+type KeywordSynonym struct {
+	gorm.Model
+	Alias     string `gorm:"not null;uniqueIndex"` // Key of the alias
+	KeywordID uint   `gorm:"not null;index"`       // Canonical keyword the alias resolves to
+}
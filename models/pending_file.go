@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PendingFile is an indexed file whose LLM enrichment (embedding and
+// keywords) failed and is queued to be retried.
+type PendingFile struct {
+	gorm.Model
+	FileIndexID   uint      `gorm:"not null;uniqueIndex"` // Foreign key linking to FileIndex
+	Attempts      int       `gorm:"not null;default:0"`   // Failed attempts so far
+	NextAttemptAt time.Time `gorm:"not null;index"`       // Earliest time to retry
+	LastError     string
+}
@@ -0,0 +1,282 @@
+// Package entities recognises and normalises named entities: people,
+// organisations, places, dates and monetary amounts. Dates and amounts follow
+// regular enough formats to be found by pattern matching; names need a
+// language model, and are only normalised here.
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the kind of an entity.
+type Type string
+
+const (
+	Person       Type = "person"
+	Organization Type = "organization"
+	Place        Type = "place"
+	Date         Type = "date"
+	Amount       Type = "amount"
+)
+
+// Types lists every entity type.
+var Types = []Type{Person, Organization, Place, Date, Amount}
+
+// ErrUnknownType is returned by ParseType for a name that is not an entity type.
+var ErrUnknownType = errors.New("unknown entity type")
+
+// ParseType validates an entity type name, also accepting "org" and
+// "organisation" for organizations.
+func ParseType(name string) (Type, error) {
+	switch name {
+	case "org", "organisation":
+		return Organization, nil
+	}
+	for _, t := range Types {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownType, name)
+}
+
+// Entity is a named entity as found in a text.
+type Entity struct {
+	Type Type
+	Text string // As written in the text
+	// Value is the normalised form: "2024-03-15" for dates, "1250.00 EUR"
+	// for amounts and the name with collapsed whitespace otherwise
+	Value string
+
+	Date     time.Time // Set for dates
+	Amount   float64   // Set for amounts
+	Currency string    // ISO 4217 code for amounts, when known
+}
+
+// New normalises text as an entity of type t. It reports false if text is
+// empty, or not a date or amount when one is expected.
+func New(t Type, text string) (Entity, bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return Entity{}, false
+	}
+
+	e := Entity{Type: t, Text: text, Value: text}
+	switch t {
+	case Date:
+		date, ok := ParseDate(text)
+		if !ok {
+			return Entity{}, false
+		}
+		e.Date = date
+		e.Value = date.Format(time.DateOnly)
+	case Amount:
+		amount, currency, ok := ParseAmount(text)
+		if !ok {
+			return Entity{}, false
+		}
+		e.Amount, e.Currency = amount, currency
+		e.Value = strconv.FormatFloat(amount, 'f', 2, 64)
+		if currency != "" {
+			e.Value += " " + currency
+		}
+	case Person, Organization, Place:
+	default:
+		return Entity{}, false
+	}
+	return e, true
+}
+
+// Key identifies an entity regardless of how it was written, so that
+// "ACME Corp" and "Acme  Corp" are the same organisation.
+func (e Entity) Key() string {
+	return string(e.Type) + ":" + strings.ToLower(e.Value)
+}
+
+// Dedupe removes entities with the same key, keeping the first.
+func Dedupe(list []Entity) []Entity {
+	seen := make(map[string]bool, len(list))
+	out := list[:0]
+	for _, e := range list {
+		if seen[e.Key()] {
+			continue
+		}
+		seen[e.Key()] = true
+		out = append(out, e)
+	}
+	return out
+}
+
+// Pattern matching
+
+// number matches digits with optional thousands separators (including Indian
+// grouping such as 1,00,000) and decimals.
+const number = `\d(?:[\d,]*\d)?(?:\.\d+)?`
+
+var (
+	isoDate     = regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`)
+	numericDate = regexp.MustCompile(`\b(\d{1,2})[/.](\d{1,2})[/.](\d{4})\b`)
+	dayMonth    = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?\s+(` + monthNames + `)\.?,?\s+(\d{4})\b`)
+	monthDay    = regexp.MustCompile(`(?i)\b(` + monthNames + `)\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+
+	symbolAmount = regexp.MustCompile(`([$€£₹¥])\s?(` + number + `)`)
+	codeAmount   = regexp.MustCompile(`(?i)\b(` + currencyCodes + `)\.?\s?(` + number + `)`)
+	amountCode   = regexp.MustCompile(`(?i)\b(` + number + `)\s?(` + currencyCodes + `)\b`)
+	bareAmount   = regexp.MustCompile(`^` + number + `$`)
+)
+
+const (
+	monthNames    = `jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?`
+	currencyCodes = `usd|eur|gbp|inr|jpy|cny|chf|cad|aud|rs`
+)
+
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"₹": "INR",
+	"¥": "JPY",
+}
+
+// Scan finds the dates and amounts in text, in order of appearance, without
+// duplicates.
+func Scan(text string) []Entity {
+	type match struct {
+		start  int
+		entity Entity
+	}
+	var matches []match
+	// taken marks spans already claimed, so "USD 1,200" is not also read as
+	// an amount without a currency
+	var taken [][]int
+
+	find := func(re *regexp.Regexp, t Type) {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if overlaps(taken, loc) {
+				continue
+			}
+			if e, ok := New(t, text[loc[0]:loc[1]]); ok {
+				matches = append(matches, match{loc[0], e})
+				taken = append(taken, loc)
+			}
+		}
+	}
+
+	for _, re := range []*regexp.Regexp{isoDate, dayMonth, monthDay, numericDate} {
+		find(re, Date)
+	}
+	for _, re := range []*regexp.Regexp{symbolAmount, codeAmount, amountCode} {
+		find(re, Amount)
+	}
+
+	// Restore document order, which the per-pattern passes lost
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	found := make([]Entity, len(matches))
+	for i, m := range matches {
+		found[i] = m.entity
+	}
+	return Dedupe(found)
+}
+
+func overlaps(spans [][]int, loc []int) bool {
+	for _, span := range spans {
+		if loc[0] < span[1] && span[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDate reads a calendar date written as 2024-03-15, 15/03/2024 (day
+// first unless that is impossible), 15 March 2024 or March 15, 2024. The date
+// is midnight local time, as search date ranges are.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+
+	var year, month, day int
+	if m := whole(isoDate, s); m != nil {
+		year, month, day = atoi(m[1]), atoi(m[2]), atoi(m[3])
+	} else if m := whole(numericDate, s); m != nil {
+		day, month, year = atoi(m[1]), atoi(m[2]), atoi(m[3])
+		if month > 12 && day <= 12 {
+			day, month = month, day
+		}
+	} else if m := whole(dayMonth, s); m != nil {
+		day, month, year = atoi(m[1]), monthNumber(m[2]), atoi(m[3])
+	} else if m := whole(monthDay, s); m != nil {
+		month, day, year = monthNumber(m[1]), atoi(m[2]), atoi(m[3])
+	} else {
+		return time.Time{}, false
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	// Reject dates such as 31/02/2024 that time.Date would roll over
+	if date.Day() != day || int(date.Month()) != month || year < 1000 {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// ParseAmount reads a monetary amount such as "$1,200.50", "EUR 99",
+// "Rs. 1,00,000" or "250 GBP", returning the value and ISO currency code. A
+// bare number is accepted with no currency.
+func ParseAmount(s string) (float64, string, bool) {
+	s = strings.TrimSpace(s)
+
+	var digits, currency string
+	if m := whole(symbolAmount, s); m != nil {
+		digits, currency = m[2], currencySymbols[m[1]]
+	} else if m := whole(codeAmount, s); m != nil {
+		digits, currency = m[2], currencyCode(m[1])
+	} else if m := whole(amountCode, s); m != nil {
+		digits, currency = m[1], currencyCode(m[2])
+	} else if bareAmount.MatchString(s) {
+		digits = s
+	} else {
+		return 0, "", false
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(digits, ",", ""), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return value, currency, true
+}
+
+// whole returns the submatches of re if it matches all of s, or nil.
+func whole(re *regexp.Regexp, s string) []string {
+	if m := re.FindStringSubmatch(s); m != nil && m[0] == s {
+		return m
+	}
+	return nil
+}
+
+func currencyCode(code string) string {
+	code = strings.ToUpper(code)
+	if code == "RS" {
+		return "INR"
+	}
+	return code
+}
+
+func monthNumber(name string) int {
+	prefix := strings.ToLower(name)[:3]
+	for m := time.January; m <= time.December; m++ {
+		if strings.ToLower(m.String())[:3] == prefix {
+			return int(m)
+		}
+	}
+	return 0
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
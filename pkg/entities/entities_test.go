@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	text := `Invoice dated 15 March 2024, due 2024-04-14.
+Total: $1,250.50 (Rs. 1,00,000 or 980 GBP). Previous invoice of 03/02/2024
+for USD 1,250.50 was paid on March 1st, 2024.`
+
+	want := []string{
+		"date:2024-03-15",
+		"date:2024-04-14",
+		"amount:1250.50 USD",
+		"amount:100000.00 INR",
+		"amount:980.00 GBP",
+		"date:2024-02-03",
+		"date:2024-03-01",
+	}
+
+	found := Scan(text)
+	if len(found) != len(want) {
+		t.Fatalf("Expected %d entities, got %d: %+v", len(want), len(found), found)
+	}
+	for i, e := range found {
+		if got := string(e.Type) + ":" + e.Value; got != want[i] {
+			t.Errorf("Entity %d: expected %s, got %s (from %q)", i, want[i], got, e.Text)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"2024-03-15", "2024-03-15", true},
+		{"15/03/2024", "2024-03-15", true},
+		{"03/15/2024", "2024-03-15", true}, // Month first only when day first is impossible
+		{"05.06.2024", "2024-06-05", true},
+		{"Sept 9, 2023", "2023-09-09", true},
+		{"31/02/2024", "", false},
+		{"next Tuesday", "", false},
+	}
+
+	for _, tt := range tests {
+		date, ok := ParseDate(tt.input)
+		if ok != tt.ok {
+			t.Errorf("ParseDate(%q): expected ok=%v, got %v", tt.input, tt.ok, ok)
+			continue
+		}
+		if ok && date.Format(time.DateOnly) != tt.want {
+			t.Errorf("ParseDate(%q): expected %s, got %s", tt.input, tt.want, date.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		amount   float64
+		currency string
+		ok       bool
+	}{
+		{"€99", 99, "EUR", true},
+		{"eur 99.90", 99.9, "EUR", true},
+		{"1,200", 1200, "", true},
+		{"NaN", 0, "", false},
+		{"a lot", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		amount, currency, ok := ParseAmount(tt.input)
+		if ok != tt.ok || amount != tt.amount || currency != tt.currency {
+			t.Errorf("ParseAmount(%q) = %v, %q, %v; expected %v, %q, %v", tt.input, amount, currency, ok, tt.amount, tt.currency, tt.ok)
+		}
+	}
+}
+
+func TestNewNormalisesNames(t *testing.T) {
+	a, _ := New(Organization, "ACME  Corp")
+	b, _ := New(Organization, " Acme Corp\n")
+	if a.Key() != b.Key() {
+		t.Errorf("Expected %q and %q to share a key", a.Text, b.Text)
+	}
+
+	if _, ok := New(Person, "   "); ok {
+		t.Error("Expected an empty name to be rejected")
+	}
+	if got := Dedupe([]Entity{a, b}); len(got) != 1 {
+		t.Errorf("Expected Dedupe to keep one entity, got %d", len(got))
+	}
+}
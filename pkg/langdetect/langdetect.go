@@ -0,0 +1,152 @@
+// Package langdetect guesses the language of a text. Scripts used by a
+// single supported language (Devanagari for Hindi) decide on their own;
+// Latin-script languages are told apart by their most common words.
+package langdetect
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultSearchConfig is the PostgreSQL text search configuration for text
+// whose language is unknown.
+const DefaultSearchConfig = "english"
+
+const (
+	// sampleWords bounds how much of a text is examined
+	sampleWords = 2000
+	// minStopWords is how many common words must be seen to decide
+	minStopWords = 3
+	// minScriptShare is the share of letters a script needs to decide
+	minScriptShare = 0.5
+)
+
+type language struct {
+	name         string
+	searchConfig string
+	script       *unicode.RangeTable // Set for languages recognised by script
+	stopWords    map[string]bool
+}
+
+// languages are the supported languages by ISO 639-1 code. Hindi has no
+// PostgreSQL stemmer, so its words are indexed as they are.
+var languages = map[string]language{
+	"en": {name: "English", searchConfig: "english", stopWords: set(`the and of to in is that for it with as was on be by this are from at or an have not which but`)},
+	"de": {name: "German", searchConfig: "german", stopWords: set(`der die das und ist nicht ein eine zu den von mit sich des auf für im dem auch es werden aus er sie wir ich oder bei`)},
+	"fr": {name: "French", searchConfig: "french", stopWords: set(`le la les et est des une un du que qui dans pour pas sur au avec ce il sont par plus`)},
+	"es": {name: "Spanish", searchConfig: "spanish", stopWords: set(`el la los las y es que del un una por con para se no al lo como más su`)},
+	"hi": {name: "Hindi", searchConfig: "simple", script: unicode.Devanagari},
+}
+
+// Result is a detected language.
+type Result struct {
+	Code       string  `json:"code"`       // ISO 639-1 code, empty if undetermined
+	Confidence float64 `json:"confidence"` // 0-1
+}
+
+// Detect guesses the language of text, returning an empty Code if it cannot
+// tell, e.g. for text that is too short or in an unsupported language.
+func Detect(text string) Result {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r)
+	})
+	if len(words) > sampleWords {
+		words = words[:sampleWords]
+	}
+
+	// A script of its own decides outright
+	var letters int
+	scripts := make(map[string]int)
+	for _, word := range words {
+		for _, r := range word {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			letters++
+			for code, lang := range languages {
+				if lang.script != nil && unicode.Is(lang.script, r) {
+					scripts[code]++
+				}
+			}
+		}
+	}
+	for code, count := range scripts {
+		if share := float64(count) / float64(letters); share >= minScriptShare {
+			return Result{Code: code, Confidence: share}
+		}
+	}
+
+	hits := make(map[string]int)
+	var total int
+	for _, word := range words {
+		for code, lang := range languages {
+			if lang.stopWords[word] {
+				hits[code]++
+				total++
+			}
+		}
+	}
+
+	best := Result{}
+	bestHits := 0
+	for _, code := range Codes() {
+		if hits[code] > bestHits {
+			best, bestHits = Result{Code: code}, hits[code]
+		}
+	}
+	if bestHits < minStopWords {
+		return Result{}
+	}
+	best.Confidence = float64(bestHits) / float64(total)
+	return best
+}
+
+// Codes lists the supported language codes in order.
+func Codes() []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Name returns the English name of a language, or code itself if unsupported.
+func Name(code string) string {
+	if lang, ok := languages[code]; ok {
+		return lang.name
+	}
+	return code
+}
+
+// SearchConfig returns the PostgreSQL text search configuration for a
+// language, or DefaultSearchConfig if it is unknown.
+func SearchConfig(code string) string {
+	if lang, ok := languages[code]; ok {
+		return lang.searchConfig
+	}
+	return DefaultSearchConfig
+}
+
+// SearchConfigs lists every configuration SearchConfig can return, in order.
+func SearchConfigs() []string {
+	seen := map[string]bool{DefaultSearchConfig: true}
+	configs := []string{DefaultSearchConfig}
+	for _, code := range Codes() {
+		if config := languages[code].searchConfig; !seen[config] {
+			seen[config] = true
+			configs = append(configs, config)
+		}
+	}
+	sort.Strings(configs)
+	return configs
+}
+
+func set(words string) map[string]bool {
+	s := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		s[word] = true
+	}
+	return s
+}
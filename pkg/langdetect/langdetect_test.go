@@ -0,0 +1,48 @@
+package langdetect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"English", "The invoice is due at the end of the month and should be paid by bank transfer.", "en"},
+		{"German", "Die Rechnung ist bis zum Ende des Monats fällig und wird mit der Überweisung bezahlt.", "de"},
+		{"French", "La facture est payable à la fin du mois et doit être réglée par virement dans les délais.", "fr"},
+		{"Spanish", "La factura vence al final del mes y se paga por transferencia con el banco.", "es"},
+		{"Hindi", "यह चालान महीने के अंत तक देय है और बैंक हस्तांतरण से भुगतान किया जाना चाहिए।", "hi"},
+		{"Too short", "Invoice 2024", ""},
+		{"Numbers only", "12 34 56 78", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.text)
+			if got.Code != tt.want {
+				t.Errorf("Expected %q, got %+v", tt.want, got)
+			}
+			if got.Code != "" && (got.Confidence <= 0 || got.Confidence > 1) {
+				t.Errorf("Confidence out of range: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSearchConfig(t *testing.T) {
+	if got := SearchConfig("de"); got != "german" {
+		t.Errorf("Expected german, got %s", got)
+	}
+	if got := SearchConfig(""); got != DefaultSearchConfig {
+		t.Errorf("Expected %s for an unknown language, got %s", DefaultSearchConfig, got)
+	}
+
+	want := []string{"english", "french", "german", "simple", "spanish"}
+	if got := SearchConfigs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
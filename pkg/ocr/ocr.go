@@ -0,0 +1,170 @@
+// Package ocr recognises text in images and scanned PDFs by running a locally
+// installed tesseract, with PDF pages rasterised by pdftoppm from poppler.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLanguages are the tesseract languages used when none are configured
+	DefaultLanguages = "eng"
+	// DefaultPageLimit is how many pages of a PDF are recognised by default
+	DefaultPageLimit = 10
+	// resolution is the DPI PDF pages are rasterised at; tesseract is most
+	// accurate at around 300
+	resolution = 300
+)
+
+// ErrNotInstalled is returned by New when tesseract cannot be found.
+var ErrNotInstalled = errors.New("tesseract is not installed")
+
+// imageExtensions are the image formats tesseract reads.
+var imageExtensions = []string{".png", ".jpg", ".jpeg", ".tif", ".tiff", ".bmp", ".gif", ".webp", ".pnm"}
+
+// Config configures OCR.
+type Config struct {
+	Enabled bool
+	// Languages are tesseract language codes joined by "+", e.g. "eng+hin"
+	Languages string
+	// PageLimit bounds how many pages of each PDF are recognised
+	PageLimit int
+}
+
+// OCR recognises text with the tesseract binary found at startup.
+type OCR struct {
+	tesseract string
+	pdftoppm  string // Empty if poppler is not installed, so PDFs are skipped
+	languages string
+	pageLimit int
+}
+
+// New finds tesseract, and pdftoppm for PDFs, on the PATH and checks that the
+// configured languages are installed.
+func New(config Config) (*OCR, error) {
+	tesseract, err := exec.LookPath("tesseract")
+	if err != nil {
+		return nil, ErrNotInstalled
+	}
+	// PDFs need pdftoppm, but images can be recognised without it
+	pdftoppm, _ := exec.LookPath("pdftoppm")
+
+	o := &OCR{
+		tesseract: tesseract,
+		pdftoppm:  pdftoppm,
+		languages: config.Languages,
+		pageLimit: config.PageLimit,
+	}
+	if o.languages == "" {
+		o.languages = DefaultLanguages
+	}
+	if o.pageLimit <= 0 {
+		o.pageLimit = DefaultPageLimit
+	}
+
+	installed, err := o.installedLanguages()
+	if err != nil {
+		return nil, err
+	}
+	for _, lang := range strings.Split(o.languages, "+") {
+		if !slices.Contains(installed, lang) {
+			return nil, fmt.Errorf("tesseract language %q is not installed (have %s)", lang, strings.Join(installed, ", "))
+		}
+	}
+	return o, nil
+}
+
+// CanExtract reports whether text in filePath can be recognised.
+func (o *OCR) CanExtract(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".pdf" {
+		return o.pdftoppm != ""
+	}
+	return slices.Contains(imageExtensions, ext)
+}
+
+// ExtractText recognises the text of an image, or of the first pages of a
+// PDF up to the page limit.
+func (o *OCR) ExtractText(ctx context.Context, filePath string) (string, error) {
+	if !o.CanExtract(filePath) {
+		return "", fmt.Errorf("unsupported file type: %s", filepath.Ext(filePath))
+	}
+	if strings.EqualFold(filepath.Ext(filePath), ".pdf") {
+		return o.extractPDF(ctx, filePath)
+	}
+	return o.recognise(ctx, filePath)
+}
+
+// extractPDF rasterises the PDF's pages into a temporary directory and
+// recognises each in turn.
+func (o *OCR) extractPDF(ctx context.Context, filePath string) (string, error) {
+	dir, err := os.MkdirTemp("", "prabandh-ocr-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.CommandContext(ctx, o.pdftoppm,
+		"-r", strconv.Itoa(resolution), "-gray", "-png",
+		"-f", "1", "-l", strconv.Itoa(o.pageLimit),
+		filePath, filepath.Join(dir, "page"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("rasterising %s: %w: %s", filePath, err, bytes.TrimSpace(out))
+	}
+
+	// Page numbers are zero-padded to the same width, so they sort as strings
+	pages, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(pages)
+
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		text, err := o.recognise(ctx, page)
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, "\n\f\n"), nil
+}
+
+// recognise runs tesseract on one image.
+func (o *OCR) recognise(ctx context.Context, imagePath string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, o.tesseract, imagePath, "stdout", "-l", o.languages)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("recognising %s: %w: %s", imagePath, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// installedLanguages lists the languages tesseract has data for.
+func (o *OCR) installedLanguages() ([]string, error) {
+	out, err := exec.Command(o.tesseract, "--list-langs").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing tesseract languages: %w", err)
+	}
+
+	// The first line is a heading naming the data directory
+	var languages []string
+	for _, line := range strings.Split(string(out), "\n")[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			languages = append(languages, line)
+		}
+	}
+	return languages, nil
+}
@@ -0,0 +1,100 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeTesseract lists eng and hin as installed and "recognises" an image as
+// its file name.
+const fakeTesseract = `#!/bin/sh
+if [ "$1" = "--list-langs" ]; then
+	echo 'List of available languages in "/usr/share/tessdata/" (2):'
+	echo eng
+	echo hin
+	exit 0
+fi
+echo "text of $(basename "$1") in $4"
+`
+
+// fakePdftoppm writes three pages, or as many as -l allows, as empty files.
+const fakePdftoppm = `#!/bin/sh
+last=3
+while [ $# -gt 1 ]; do
+	if [ "$1" = "-l" ] && [ "$2" -lt "$last" ]; then last=$2; fi
+	shift
+done
+i=1
+while [ $i -le $last ]; do
+	: > "$1-$i.png"
+	i=$((i + 1))
+done
+`
+
+// installFakes puts the given scripts on an otherwise empty PATH.
+func installFakes(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+"/bin"+string(os.PathListSeparator)+"/usr/bin")
+}
+
+func TestNew_NotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := New(Config{Enabled: true}); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("expected ErrNotInstalled, got %v", err)
+	}
+}
+
+func TestNew_MissingLanguage(t *testing.T) {
+	installFakes(t, map[string]string{"tesseract": fakeTesseract})
+	if _, err := New(Config{Enabled: true, Languages: "eng+deu"}); err == nil {
+		t.Error("expected an error for a language that is not installed")
+	}
+}
+
+func TestExtractText_Image(t *testing.T) {
+	installFakes(t, map[string]string{"tesseract": fakeTesseract})
+	o, err := New(Config{Enabled: true, Languages: "eng+hin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !o.CanExtract("receipt.JPG") || o.CanExtract("notes.txt") {
+		t.Error("CanExtract should accept images only")
+	}
+	if o.CanExtract("scan.pdf") {
+		t.Error("PDFs need pdftoppm")
+	}
+
+	text, err := o.ExtractText(context.Background(), "receipt.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "text of receipt.jpg in eng+hin"; text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+}
+
+func TestExtractText_PDFPageLimit(t *testing.T) {
+	installFakes(t, map[string]string{"tesseract": fakeTesseract, "pdftoppm": fakePdftoppm})
+	o, err := New(Config{Enabled: true, PageLimit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := o.ExtractText(context.Background(), "scan.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "text of page-1.png in eng\n\f\ntext of page-2.png in eng"; text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Package rake extracts key phrases from text with RAKE (Rapid Automatic
+// Keyword Extraction): candidate phrases are the runs of words between stop
+// words and punctuation, and each word is scored by how often it appears in
+// longer phrases relative to how often it appears at all.
+package rake
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Phrase is a candidate key phrase and its score.
+type Phrase struct {
+	Text  string
+	Score float64
+}
+
+// maxPhraseWords drops runs that are really sentence fragments.
+const maxPhraseWords = 4
+
+// Extract returns up to n key phrases of text, best first. Ties are broken by
+// first appearance, so the result is stable for the same text.
+func Extract(text string, n int) []Phrase {
+	candidates := split(text)
+
+	frequency := make(map[string]int)
+	degree := make(map[string]int)
+	for _, words := range candidates {
+		for _, word := range words {
+			frequency[word]++
+			degree[word] += len(words) - 1
+		}
+	}
+
+	seen := make(map[string]bool)
+	var phrases []Phrase
+	for _, words := range candidates {
+		phrase := strings.Join(words, " ")
+		if seen[phrase] {
+			continue
+		}
+		seen[phrase] = true
+
+		var score float64
+		for _, word := range words {
+			score += float64(degree[word]+frequency[word]) / float64(frequency[word])
+		}
+		phrases = append(phrases, Phrase{Text: phrase, Score: score})
+	}
+
+	sort.SliceStable(phrases, func(i, j int) bool { return phrases[i].Score > phrases[j].Score })
+	if len(phrases) > n {
+		phrases = phrases[:n]
+	}
+	return phrases
+}
+
+// split breaks text into candidate phrases of lower-cased words. Phrases end
+// at stop words, numbers and punctuation other than apostrophes and hyphens.
+func split(text string) [][]string {
+	var candidates [][]string
+	var current []string
+
+	flush := func() {
+		if len(current) > 0 && len(current) <= maxPhraseWords {
+			candidates = append(candidates, current)
+		}
+		current = nil
+	}
+
+	for _, sentence := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || unicode.IsPunct(r) && r != '\'' && r != '-'
+	}) {
+		for _, token := range strings.Fields(sentence) {
+			word := strings.ToLower(strings.Trim(token, "'-"))
+			if stopWords[word] || !hasLetter(word) || len([]rune(word)) < 2 {
+				flush()
+				continue
+			}
+			current = append(current, word)
+		}
+		flush()
+	}
+	return candidates
+}
+
+func hasLetter(word string) bool {
+	return strings.IndexFunc(word, unicode.IsLetter) >= 0
+}
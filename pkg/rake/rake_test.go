@@ -0,0 +1,43 @@
+package rake
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	text := `Quarterly tax return for Acme Corp.
+The quarterly tax return is due in April, and the tax advisor will review it.`
+
+	var got []string
+	for _, p := range Extract(text, 3) {
+		got = append(got, p.Text)
+	}
+
+	want := []string{"quarterly tax return", "tax advisor", "acme corp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestExtractIsStable(t *testing.T) {
+	text := "alpha beta, gamma delta, epsilon zeta"
+	first := Extract(text, 10)
+	for i := 0; i < 5; i++ {
+		if again := Extract(text, 10); !reflect.DeepEqual(first, again) {
+			t.Fatalf("Expected the same phrases on every run, got %v then %v", first, again)
+		}
+	}
+	if first[0].Text != "alpha beta" {
+		t.Errorf("Expected ties to keep their order of appearance, got %v", first)
+	}
+}
+
+func TestExtractSkipsNumbersAndStopWords(t *testing.T) {
+	for _, p := range Extract("The 2024 total was 1,250 and it is not the end", 10) {
+		switch p.Text {
+		case "the", "2024", "1,250", "and", "it", "is":
+			t.Errorf("Unexpected phrase %q", p.Text)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package rake
+
+import "strings"
+
+// stopWords end candidate phrases. The list is the common English function
+// words, plus a few filler words that are never useful as keywords.
+var stopWords = toSet(`a about above after again against all also am an and any are as at
+be because been before being below between both but by can cannot could did do does
+doing down during each either else etc even ever every few for from further get gets
+got had has have having he her here hers herself him himself his how however i if in
+into is it its itself just least less let like made make many may me might more most
+much must my myself neither no nor not now of off often on once one only or other our
+ours ourselves out over own per please rather same several shall she should since so
+some such than that the their theirs them themselves then there these they this those
+though through thus to too under until up upon us use used using very via was we well
+were what when where whether which while who whom whose why will with within without
+would yet you your yours yourself yourselves`)
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
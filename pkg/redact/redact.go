@@ -0,0 +1,173 @@
+// Package redact finds secrets and personal data in text and masks them, so
+// that text can be sent to a model server that may not be local.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind is the kind of sensitive data found.
+type Kind string
+
+const (
+	APIKey     Kind = "api_key"
+	PrivateKey Kind = "private_key"
+	Password   Kind = "password"
+	CreditCard Kind = "credit_card"
+	Email      Kind = "email"
+	Phone      Kind = "phone"
+)
+
+// Sensitive reports whether finding data of this kind makes a file
+// sensitive. Emails and phone numbers appear in most correspondence, so they
+// are masked without flagging the file.
+func (k Kind) Sensitive() bool {
+	switch k {
+	case APIKey, PrivateKey, Password, CreditCard:
+		return true
+	}
+	return false
+}
+
+// Finding is a span of text holding sensitive data.
+type Finding struct {
+	Kind  Kind
+	Start int
+	End   int
+}
+
+type detector struct {
+	kind Kind
+	re   *regexp.Regexp
+	// group is the submatch holding the data, e.g. the value of a password
+	// assignment; 0 for the whole match
+	group int
+	valid func(string) bool
+}
+
+// detectors run in order; a span claimed by one is not reported again.
+var detectors = []detector{
+	{kind: PrivateKey, re: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`)},
+	{kind: APIKey, re: regexp.MustCompile(`\b(?:AKIA[0-9A-Z]{16}|ASIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|sk-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,}|glpat-[A-Za-z0-9_-]{20,})`)},
+	// Assignments such as DB_PASSWORD=hunter2 in .env files, or "token: abc" in YAML
+	{kind: Password, group: 1, re: regexp.MustCompile(`(?im)^[ \t]*(?:export[ \t]+)?[a-z0-9_.-]*(?:password|passwd|pwd|secret|token|api_?key|access_?key)[a-z0-9_.-]*[ \t]*[=:][ \t]*["']?([^\s"'#]+)`)},
+	{kind: CreditCard, re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhn},
+	{kind: Email, re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{kind: Phone, re: regexp.MustCompile(`\+\d{1,3}[ .-]?(?:\(\d{1,4}\)[ .-]?)?\d{2,5}(?:[ .-]?\d{2,5}){1,3}|\(\d{3}\)[ .-]?\d{3}[ .-]\d{4}|\b\d{3}[.-]\d{3}[.-]\d{4}\b`), valid: phoneDigits},
+}
+
+// Scan finds the sensitive data in text, in order of appearance.
+func Scan(text string) []Finding {
+	var findings []Finding
+	for _, d := range detectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*d.group], m[2*d.group+1]
+			if start < 0 || overlaps(findings, start, end) {
+				continue
+			}
+			if d.valid != nil && !d.valid(text[start:end]) {
+				continue
+			}
+			findings = append(findings, Finding{Kind: d.kind, Start: start, End: end})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return findings
+}
+
+// Mask replaces the sensitive data in text with placeholders such as
+// "[REDACTED:email]" and returns the masked text with what was found.
+func Mask(text string) (string, []Finding) {
+	findings := Scan(text)
+	if len(findings) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Start])
+		b.WriteString("[REDACTED:" + string(f.Kind) + "]")
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String(), findings
+}
+
+// Sensitive reports whether any finding makes the text sensitive.
+func Sensitive(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Kind.Sensitive() {
+			return true
+		}
+	}
+	return false
+}
+
+func overlaps(findings []Finding, start, end int) bool {
+	for _, f := range findings {
+		if start < f.End && f.Start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// luhn validates a card number by its check digit, ignoring spaces and dashes.
+func luhn(number string) bool {
+	var sum, count int
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		count++
+		double = !double
+	}
+	return count >= 13 && count <= 19 && sum%10 == 0
+}
+
+// phoneDigits rules out matches too short or long to be phone numbers.
+func phoneDigits(number string) bool {
+	count := 0
+	for _, c := range number {
+		if c >= '0' && c <= '9' {
+			count++
+		}
+	}
+	return count >= 8 && count <= 15
+}
+
+// Mode selects what happens to sensitive data before text is sent to a model.
+type Mode string
+
+const (
+	// ModeMask masks sensitive data and sends the rest
+	ModeMask Mode = "mask"
+	// ModeRefuse masks sensitive data, and sends nothing from sensitive files
+	ModeRefuse Mode = "refuse"
+	// ModeOff sends text as it is
+	ModeOff Mode = "off"
+)
+
+// ParseMode validates a mode name; an empty name is ModeMask.
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return ModeMask, nil
+	case ModeMask, ModeRefuse, ModeOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown redaction mode %q (use %s, %s or %s)", name, ModeMask, ModeRefuse, ModeOff)
+}
@@ -0,0 +1,81 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Backoff configures retries with exponential backoff and full jitter.
+type Backoff struct {
+	Base     time.Duration // Delay ceiling before the first retry
+	Max      time.Duration // Upper bound on any delay
+	Attempts int           // Total attempts, including the first
+}
+
+// Delay returns a random delay for the given retry (0 for the first retry),
+// drawn uniformly from [0, min(Max, Base*2^retry)].
+func (b Backoff) Delay(retry int) time.Duration {
+	ceiling := b.Base
+	for i := 0; i < retry && ceiling < b.Max; i++ {
+		ceiling *= 2
+	}
+	if ceiling > b.Max {
+		ceiling = b.Max
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// permanent marks an error that must not be retried.
+type permanent struct {
+	err error
+}
+
+func (p *permanent) Error() string { return p.err.Error() }
+func (p *permanent) Unwrap() error { return p.err }
+
+// Permanent wraps err so Retry returns it immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanent{err: err}
+}
+
+// Retry calls fn until it succeeds, returns a Permanent error, the attempts
+// are used up or ctx is done, sleeping b.Delay between attempts. The returned
+// error is the last one from fn, unwrapped from Permanent.
+func Retry(ctx context.Context, b Backoff, fn func() error) error {
+	attempts := b.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(b.Delay(attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		var p *permanent
+		if errors.As(err, &p) {
+			return p.err
+		}
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket whose refill rate adapts to the service: it is
+// halved when calls fail (multiplicative decrease) and grows by a fixed step
+// when they succeed (additive increase), staying between min and max.
+type Limiter struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	min, max float64
+	step     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewLimiter creates a limiter starting at max tokens per second that may
+// slow down to min, allowing bursts of up to burst calls.
+func NewLimiter(min, max float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   max,
+		min:    min,
+		max:    max,
+		step:   max / 20,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available and otherwise returns how long
+// until the next one is.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Increase speeds the limiter up after a successful call.
+func (l *Limiter) Increase() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate += l.step
+	if l.rate > l.max {
+		l.rate = l.max
+	}
+}
+
+// Decrease slows the limiter down after a failed call.
+func (l *Limiter) Decrease() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate /= 2
+	if l.rate < l.min {
+		l.rate = l.min
+	}
+}
+
+// Rate returns the current refill rate in tokens per second.
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
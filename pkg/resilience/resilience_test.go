@@ -0,0 +1,138 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	t.Run("Opens after threshold failures", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Expected call %d to be allowed, got %v", i, err)
+			}
+			b.Failure()
+		}
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Errorf("Expected ErrOpen, got %v", err)
+		}
+	})
+
+	t.Run("Allows one probe after cooldown", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected probe to be allowed, got %v", err)
+		}
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Errorf("Expected second call during probe to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Failed probe reopens", func(t *testing.T) {
+		b.Failure()
+		if b.State() != Open {
+			t.Errorf("Expected open, got %s", b.State())
+		}
+	})
+
+	t.Run("Successful probe closes", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected probe to be allowed, got %v", err)
+		}
+		b.Success()
+		if b.State() != Closed {
+			t.Errorf("Expected closed, got %s", b.State())
+		}
+	})
+}
+
+func TestLimiterAdapts(t *testing.T) {
+	l := NewLimiter(1, 8, 1)
+
+	for i := 0; i < 10; i++ {
+		l.Decrease()
+	}
+	if l.Rate() != 1 {
+		t.Errorf("Expected rate to bottom out at 1, got %v", l.Rate())
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Increase()
+	}
+	if l.Rate() != 8 {
+		t.Errorf("Expected rate to top out at 8, got %v", l.Rate())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected burst token to be available, got %v", err)
+	}
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancelled wait, got %v", err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+	for retry := 0; retry < 10; retry++ {
+		ceiling := b.Base << retry
+		if ceiling > b.Max {
+			ceiling = b.Max
+		}
+		for i := 0; i < 20; i++ {
+			if d := b.Delay(retry); d < 0 || d > ceiling {
+				t.Fatalf("Delay(%d) = %s, want within [0, %s]", retry, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	b := Backoff{Base: time.Millisecond, Max: time.Millisecond, Attempts: 3}
+	transient := errors.New("transient")
+
+	t.Run("Retries until attempts run out", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), b, func() error {
+			calls++
+			return transient
+		})
+		if !errors.Is(err, transient) || calls != 3 {
+			t.Errorf("Expected 3 calls ending in transient error, got %d calls and %v", calls, err)
+		}
+	})
+
+	t.Run("Stops on success", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), b, func() error {
+			calls++
+			if calls < 2 {
+				return transient
+			}
+			return nil
+		})
+		if err != nil || calls != 2 {
+			t.Errorf("Expected success on call 2, got %d calls and %v", calls, err)
+		}
+	})
+
+	t.Run("Stops on permanent error", func(t *testing.T) {
+		fatal := errors.New("fatal")
+		calls := 0
+		err := Retry(context.Background(), b, func() error {
+			calls++
+			return Permanent(fatal)
+		})
+		if err != fatal || calls != 1 {
+			t.Errorf("Expected one call returning the unwrapped error, got %d calls and %v", calls, err)
+		}
+	})
+}
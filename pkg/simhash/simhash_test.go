@@ -0,0 +1,46 @@
+package simhash
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSimhash(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&b, "clause %d of the vendor contract covers item %d ", i, i*7)
+	}
+	base := b.String()
+	edited := strings.Replace(base, "clause 42 of", "section 42 of", 1)
+
+	b.Reset()
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&b, "pod %d in deployment %d drains replica %d ", i, i%5, i*3)
+	}
+	unrelated := b.String()
+
+	t.Run("Identical text", func(t *testing.T) {
+		if Simhash(base) != Simhash(base) {
+			t.Error("Expected identical fingerprints for identical text")
+		}
+	})
+
+	t.Run("Small edit", func(t *testing.T) {
+		if d := Distance(Simhash(base), Simhash(edited)); d > 3 {
+			t.Errorf("Expected near-identical fingerprints, got distance %d", d)
+		}
+	})
+
+	t.Run("Unrelated text", func(t *testing.T) {
+		if d := Distance(Simhash(base), Simhash(unrelated)); d < 10 {
+			t.Errorf("Expected distant fingerprints, got distance %d", d)
+		}
+	})
+
+	t.Run("Empty text", func(t *testing.T) {
+		if Simhash("  \n ") != 0 {
+			t.Error("Expected zero fingerprint for empty text")
+		}
+	})
+}
@@ -0,0 +1,12 @@
+// Package sqlutil holds helpers for building SQL from user input.
+package sqlutil
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes LIKE wildcards so user input matches literally, with
+// the backslash that LIKE uses as its default escape character.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
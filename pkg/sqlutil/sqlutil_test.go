@@ -0,0 +1,9 @@
+package sqlutil
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := EscapeLike(`50%_off\sale`), `50\%\_off\\sale`; got != want {
+		t.Errorf("EscapeLike = %q, want %q", got, want)
+	}
+}
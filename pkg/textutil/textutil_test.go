@@ -0,0 +1,19 @@
+package textutil
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	got := Truncate("ab€€", 4)
+	if !utf8.ValidString(got) {
+		t.Fatalf("Truncate produced invalid UTF-8: %q", got)
+	}
+	if got != "ab" {
+		t.Errorf("Truncate = %q, want %q", got, "ab")
+	}
+	if got := Truncate("short", 10); got != "short" {
+		t.Errorf("Truncate = %q, want unchanged", got)
+	}
+}
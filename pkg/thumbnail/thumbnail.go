@@ -0,0 +1,88 @@
+// Package thumbnail downscales images before they are sent to a vision model,
+// which sees a few hundred pixels at most and is slower the larger the upload.
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // Register decoders for the formats in Extensions
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Quality is the JPEG quality thumbnails are encoded at.
+const Quality = 85
+
+// Extensions are the image formats Downscale decodes.
+var Extensions = []string{".jpg", ".jpeg", ".png", ".gif"}
+
+// CanDecode reports whether filePath has an extension Downscale decodes.
+func CanDecode(filePath string) bool {
+	return slices.Contains(Extensions, strings.ToLower(filepath.Ext(filePath)))
+}
+
+// Downscale decodes an image and encodes it as a JPEG whose longer side is at
+// most maxSide pixels. Smaller images keep their size. Transparent areas are
+// flattened onto white.
+func Downscale(r io.Reader, maxSide int) ([]byte, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("decode image: empty image")
+	}
+	if longer := max(width, height); longer > maxSide {
+		width = max(1, width*maxSide/longer)
+		height = max(1, height*maxSide/longer)
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, resize(src, width, height), &jpeg.Options{Quality: Quality}); err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// resize scales src to width by height, averaging the source pixels that fall
+// within each destination pixel.
+func resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// Colours are premultiplied, so adding the missing alpha as white
+			// flattens the pixel onto a white background
+			white := n*0xffff - a
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8((r + white) / n >> 8)
+			dst.Pix[i+1] = uint8((g + white) / n >> 8)
+			dst.Pix[i+2] = uint8((b + white) / n >> 8)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,77 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestDownscale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+
+	out, err := Downscale(encodePNG(t, src), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if got := thumb.Bounds().Size(); got != image.Pt(100, 25) {
+		t.Errorf("size = %v, want 100x25", got)
+	}
+
+	r, g, b, _ := thumb.At(50, 12).RGBA()
+	if r>>8 < 180 || g>>8 > 70 || b>>8 > 70 {
+		t.Errorf("colour not preserved: %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestDownscale_SmallAndTransparent(t *testing.T) {
+	// A fully transparent image keeps its size and is flattened onto white
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+
+	out, err := Downscale(encodePNG(t, src), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := thumb.Bounds().Size(); got != image.Pt(20, 10) {
+		t.Errorf("size = %v, want 20x10", got)
+	}
+	if r, g, b, _ := thumb.At(5, 5).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("transparent pixel should be white, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestDownscale_NotAnImage(t *testing.T) {
+	if _, err := Downscale(bytes.NewReader([]byte("plain text")), 100); err == nil {
+		t.Error("expected an error for data that is not an image")
+	}
+}
+
+func TestCanDecode(t *testing.T) {
+	if !CanDecode("holiday/IMG_0001.JPG") || CanDecode("scan.tiff") {
+		t.Error("CanDecode should accept JPEG, PNG and GIF only")
+	}
+}
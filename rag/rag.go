@@ -0,0 +1,151 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"prabandh/llm"
+	"prabandh/llm/generation"
+	"prabandh/pkg/redact"
+	"prabandh/pkg/textutil"
+	"prabandh/search"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultSources is how many files are retrieved to answer a question.
+	DefaultSources = 5
+	// MaxSources bounds the files retrieved so the prompt fits the model's context.
+	MaxSources = 10
+
+	// maxExcerptLength bounds the excerpt taken from each source.
+	maxExcerptLength = 1500
+)
+
+// NoSourcesAnswer is returned without calling the model when nothing in the
+// index matches the question.
+const NoSourcesAnswer = "I could not find any indexed files relevant to this question."
+
+// Source is an indexed file used to ground an answer. Number is the label the
+// model cites it by.
+type Source struct {
+	Number      int     `json:"number"`
+	FileIndexID uint    `json:"file_index_id"`
+	FileName    string  `json:"file_name"`
+	FilePath    string  `json:"file_path"`
+	Score       float64 `json:"score"`
+	Excerpt     string  `json:"excerpt"`
+}
+
+// Retrieval is the context gathered to answer a question.
+type Retrieval struct {
+	Question string
+	Sources  []Source
+	Prompt   string
+}
+
+// Assistant answers questions from the content of indexed files.
+type Assistant struct {
+	searcher   *search.Searcher
+	provider   llm.Provider
+	redactMode redact.Mode
+}
+
+// NewAssistant returns an Assistant that keeps sensitive data out of prompts
+// as redactMode requires: masked, or with sensitive files left out entirely
+// in refuse mode.
+func NewAssistant(db *gorm.DB, provider llm.Provider, redactMode redact.Mode) *Assistant {
+	return &Assistant{
+		searcher:   search.NewSearcher(db, provider),
+		provider:   provider,
+		redactMode: redactMode,
+	}
+}
+
+// Retrieve finds the files most relevant to question and builds a prompt
+// grounded in their content.
+func (a *Assistant) Retrieve(ctx context.Context, question string, limit int) (*Retrieval, error) {
+	passages, err := a.searcher.Passages(ctx, question, limit, a.redactMode == redact.ModeRefuse)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sources are returned to the client as they are; only the prompt is masked
+	sources := make([]Source, len(passages))
+	outgoing := make([]Source, len(passages))
+	for i, p := range passages {
+		sources[i] = Source{
+			Number:      i + 1,
+			FileIndexID: p.ID,
+			FileName:    p.FileName,
+			FilePath:    p.FilePath,
+			Score:       p.Score,
+			Excerpt:     truncate(p.Excerpt, maxExcerptLength),
+		}
+		outgoing[i] = a.mask(sources[i], p.Excerpt)
+	}
+
+	return &Retrieval{
+		Question: question,
+		Sources:  sources,
+		Prompt:   buildPrompt(question, outgoing),
+	}, nil
+}
+
+// mask returns source as it may be sent to the model server. Unless
+// redaction is off, sensitive data is masked in its name, path and excerpt,
+// which is masked before truncation so no value is cut short of detection.
+func (a *Assistant) mask(source Source, excerpt string) Source {
+	if a.redactMode == redact.ModeOff {
+		return source
+	}
+	source.FileName, _ = redact.Mask(source.FileName)
+	source.FilePath, _ = redact.Mask(source.FilePath)
+	excerpt, _ = redact.Mask(excerpt)
+	source.Excerpt = truncate(excerpt, maxExcerptLength)
+	return source
+}
+
+// Answer streams the model's answer to r, calling onToken with each chunk,
+// until it completes or ctx is cancelled. Stats are nil when no model was called.
+func (a *Assistant) Answer(ctx context.Context, r *Retrieval, onToken func(string) error) (*generation.Stats, error) {
+	if len(r.Sources) == 0 {
+		return nil, onToken(NoSourcesAnswer)
+	}
+	return a.provider.GenerateStream(ctx, r.Prompt, onToken)
+}
+
+// buildPrompt asks the model to answer only from the numbered sources and to
+// cite them.
+func buildPrompt(question string, sources []Source) string {
+	var b strings.Builder
+	b.WriteString(`You answer questions about the user's files using only the numbered sources below.
+Cite the sources you use inline as [1], [2] and so on.
+If the sources do not contain the answer, say that you do not know. Do not make anything up.
+
+`)
+
+	for _, s := range sources {
+		fmt.Fprintf(&b, "[%d] %s (%s)\n", s.Number, s.FileName, s.FilePath)
+		if s.Excerpt != "" {
+			b.WriteString(s.Excerpt)
+		} else {
+			b.WriteString("(no extracted text)")
+		}
+		b.WriteString("\n\n")
+	}
+
+	fmt.Fprintf(&b, "Question: %s\nAnswer:", question)
+	return b.String()
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence,
+// marking it as cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return textutil.Truncate(s, n) + "..."
+}
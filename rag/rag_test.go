@@ -0,0 +1,59 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"prabandh/pkg/redact"
+)
+
+func TestBuildPromptNumbersSources(t *testing.T) {
+	prompt := buildPrompt("When is the deadline?", []Source{
+		{Number: 1, FileName: "contract.pdf", FilePath: "/docs/contract.pdf", Excerpt: "due by 30 September"},
+		{Number: 2, FileName: "notes.txt", FilePath: "/docs/notes.txt"},
+	})
+
+	for _, want := range []string{
+		"[1] contract.pdf (/docs/contract.pdf)\ndue by 30 September",
+		"[2] notes.txt (/docs/notes.txt)\n(no extracted text)",
+		"Question: When is the deadline?\nAnswer:",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestTruncateKeepsRunesWhole(t *testing.T) {
+	got := truncate("ab€€", 4)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncate produced invalid UTF-8: %q", got)
+	}
+	if got != "ab..." {
+		t.Errorf("truncate = %q, want %q", got, "ab...")
+	}
+
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("truncate = %q, want unchanged", got)
+	}
+}
+
+func TestMaskSourceForPrompt(t *testing.T) {
+	source := Source{Number: 1, FileName: "card-4111111111111111.txt", FilePath: "/docs/card-4111111111111111.txt"}
+	excerpt := "paid with 4111 1111 1111 1111 on Monday"
+
+	masked := (&Assistant{redactMode: redact.ModeMask}).mask(source, excerpt)
+	for _, field := range []string{masked.FileName, masked.FilePath, masked.Excerpt} {
+		if strings.Contains(strings.ReplaceAll(field, " ", ""), "4111111111111111") {
+			t.Errorf("card number not masked in %q", field)
+		}
+	}
+	if !strings.Contains(masked.Excerpt, "on Monday") {
+		t.Errorf("excerpt lost its other text: %q", masked.Excerpt)
+	}
+
+	if off := (&Assistant{redactMode: redact.ModeOff}).mask(source, excerpt); off != source {
+		t.Errorf("mask with redaction off changed the source: %+v", off)
+	}
+}
@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"prabandh/controllers"
+	"prabandh/llm"
+	"prabandh/pkg/redact"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterAskRoutes(r *gin.Engine, db *gorm.DB, provider llm.Provider, redactMode redact.Mode) {
+	askController := controllers.NewAskController(db, provider, redactMode)
+
+	r.POST("/ask", askController.Ask)
+}
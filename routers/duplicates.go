@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"prabandh/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDuplicateRoutes(r *gin.Engine) {
+	r.GET("/duplicates", controllers.GetDuplicates)
+}
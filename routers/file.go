@@ -10,6 +10,9 @@ func RegisterFileRoutes(r *gin.Engine) {
 	fileGroup := r.Group("/file")
 	{
 		fileGroup.POST("/add", controllers.AddFile)
-		fileGroup.GET("/search", controllers.SearchFiles)
+		fileGroup.GET("/:id/category", controllers.GetFileCategory)
+		fileGroup.PUT("/:id/category", controllers.SetFileCategory)
+		fileGroup.GET("/:id/entities", controllers.GetFileEntities)
+		fileGroup.GET("/:id/caption", controllers.GetFileCaption)
 	}
 }
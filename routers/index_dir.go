@@ -11,5 +11,6 @@ func RegisterIndexDirRoutes(r *gin.Engine) {
 	{
 		indexDirGroup.POST("/add", controllers.AddIndexDir)
 		indexDirGroup.GET("/", controllers.GetIndexDirs)
+		indexDirGroup.PUT("/:id/captions", controllers.SetIndexDirCaptions)
 	}
 }
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"prabandh/controllers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterKeywordRoutes(r *gin.Engine, db *gorm.DB) {
+	keywordController := controllers.NewKeywordController(db)
+
+	keywordGroup := r.Group("/keywords")
+	{
+		keywordGroup.GET("/complete", keywordController.CompleteKeywords)
+		keywordGroup.GET("/top", keywordController.TopKeywords)
+		keywordGroup.GET("/related", keywordController.RelatedKeywords)
+		keywordGroup.POST("/rebuild", keywordController.RebuildKeywords)
+		keywordGroup.GET("/synonyms", keywordController.GetSynonyms)
+		keywordGroup.POST("/synonyms", keywordController.AddSynonym)
+		keywordGroup.DELETE("/synonyms/:id", keywordController.DeleteSynonym)
+	}
+}
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"prabandh/controllers"
+	"prabandh/llm"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterLLMRoutes(r *gin.Engine, db *gorm.DB, provider llm.Provider) {
+	llmController := controllers.NewLLMController(db, provider)
+
+	r.GET("/llm/health", llmController.Health)
+}
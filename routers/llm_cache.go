@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"prabandh/controllers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterLLMCacheRoutes(r *gin.Engine, db *gorm.DB) {
+	llmCacheController := controllers.NewLLMCacheController(db)
+
+	llmCache := r.Group("/llm-cache")
+	{
+		llmCache.GET("/stats", llmCacheController.GetLLMCacheStats)
+		llmCache.DELETE("", llmCacheController.InvalidateLLMCache)
+	}
+}
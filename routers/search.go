@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"prabandh/controllers"
+	"prabandh/llm"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterSearchRoutes(r *gin.Engine, db *gorm.DB, provider llm.Provider) {
+	searchController := controllers.NewSearchController(db, provider)
+
+	searchGroup := r.Group("/search")
+	{
+		searchGroup.GET("", searchController.HybridSearch)
+		searchGroup.GET("/semantic", searchController.SemanticSearch)
+		searchGroup.GET("/content", searchController.ContentSearch)
+	}
+	r.GET("/file/search", searchController.SearchFiles)
+	r.GET("/file/:id/similar", searchController.SimilarFiles)
+}
@@ -2,17 +2,19 @@ package routers
 
 import (
 	"prabandh/controllers"
+	"prabandh/indexer"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func RegisterSummaryRoutes(r *gin.Engine, db *gorm.DB, ollamaURL string) {
-	summaryController := controllers.NewSummaryController(db, ollamaURL)
+func RegisterSummaryRoutes(r *gin.Engine, db *gorm.DB, fileIndexer *indexer.FileIndexer) {
+	summaryController := controllers.NewSummaryController(db, fileIndexer)
 
 	summaryGroup := r.Group("/summary")
 	{
 		summaryGroup.POST("/add", summaryController.AddFileSummary)
 		summaryGroup.GET("/", summaryController.GetFileSummaries) // Assuming you implement this method
+		summaryGroup.POST("/regenerate", summaryController.RegenerateKeywords)
 	}
 }
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"prabandh/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTaxonomyRoutes(r *gin.Engine) {
+	categoryGroup := r.Group("/categories")
+	{
+		categoryGroup.GET("", controllers.GetCategories)
+		categoryGroup.POST("", controllers.AddCategory)
+		categoryGroup.DELETE("/:id", controllers.DeleteCategory)
+		categoryGroup.GET("/files", controllers.GetCategoryFiles)
+	}
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+	"prabandh/models"
+)
+
+// ContentResult is a file whose extracted content matched a full-text query.
+type ContentResult struct {
+	models.FileIndex
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// headlineOptions configures the ts_headline snippets returned by Content.
+const headlineOptions = "StartSel=<<, StopSel=>>, MaxFragments=3, MinWords=5, MaxWords=20, FragmentDelimiter=\" ... \""
+
+// Content runs a full-text query over extracted file content and returns
+// matches with highlighted snippets showing where the query matched.
+func (s *Searcher) Content(ctx context.Context, query string, limit int) ([]ContentResult, error) {
+	var results []ContentResult
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.*, ts_rank(c.content_tsv, q) AS rank,
+			ts_headline(c.search_config, c.content, q, ?) AS snippet
+		FROM file_contents c
+		JOIN file_indices f ON f.hash = c.hash,
+			multilingual_websearch_query(?) q
+		WHERE c.deleted_at IS NULL AND f.deleted_at IS NULL AND c.content_tsv @@ q
+		ORDER BY rank DESC, f.id
+		LIMIT ?`, headlineOptions, query, limit).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// rankContent ranks matching files by full-text match of their extracted content against any word of text.
+func (s *Searcher) rankContent(ctx context.Context, text, where string, args []interface{}) ([]Ranked, error) {
+	var ranked []Ranked
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id, ts_rank(c.content_tsv, q) AS score
+		FROM file_contents c
+		JOIN file_indices f ON f.hash = c.hash,
+			multilingual_websearch_query(?) q
+		WHERE c.deleted_at IS NULL AND f.deleted_at IS NULL AND c.content_tsv @@ q
+			AND `+where+`
+		ORDER BY score DESC, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{anyTerm(text)}, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
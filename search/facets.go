@@ -0,0 +1,77 @@
+package search
+
+import "context"
+
+// facetLimit bounds the number of values returned per facet.
+const facetLimit = 20
+
+// FacetCount is the number of matching files sharing a facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facets summarise the full set of files matching a query.
+type Facets struct {
+	Extension []FacetCount `json:"extension"`
+	Directory []FacetCount `json:"directory"`
+	Year      []FacetCount `json:"year"`
+	Keyword   []FacetCount `json:"keyword"`
+}
+
+// directoryExpr resolves a file to the top-level directory beneath the most specific
+// IndexDir that contains it, falling back to the file's parent directory.
+const directoryExpr = `CASE
+		WHEN d.location IS NULL THEN regexp_replace(f.file_path, '/[^/]*$', '')
+		WHEN strpos(substr(f.file_path, length(d.location) + 2), '/') > 0
+			THEN d.location || '/' || split_part(substr(f.file_path, length(d.location) + 2), '/', 1)
+		ELSE d.location
+	END`
+
+func (s *Searcher) facets(ctx context.Context, q *Query) (*Facets, error) {
+	where, args := q.Where()
+	facets := &Facets{}
+
+	queries := []struct {
+		dest *[]FacetCount
+		sql  string
+	}{
+		{&facets.Extension, `
+			SELECT LOWER(f.extension) AS value, COUNT(*) AS count
+			FROM file_indices f
+			WHERE f.deleted_at IS NULL AND ` + where + `
+			GROUP BY 1 ORDER BY count DESC, value LIMIT ?`},
+		{&facets.Directory, `
+			SELECT ` + directoryExpr + ` AS value, COUNT(*) AS count
+			FROM file_indices f
+			LEFT JOIN LATERAL (
+				SELECT rtrim(directory_location, '/') AS location
+				FROM index_dirs
+				WHERE deleted_at IS NULL AND f.file_path LIKE rtrim(directory_location, '/') || '/%'
+				ORDER BY length(directory_location) DESC
+				LIMIT 1
+			) d ON TRUE
+			WHERE f.deleted_at IS NULL AND ` + where + `
+			GROUP BY 1 ORDER BY count DESC, value LIMIT ?`},
+		{&facets.Year, `
+			SELECT EXTRACT(YEAR FROM f.modified_date)::int::text AS value, COUNT(*) AS count
+			FROM file_indices f
+			WHERE f.deleted_at IS NULL AND ` + where + `
+			GROUP BY 1 ORDER BY value DESC LIMIT ?`},
+		{&facets.Keyword, `
+			SELECT k.name AS value, COUNT(DISTINCT f.id) AS count
+			FROM file_indices f
+			JOIN file_keywords fk ON fk.file_index_id = f.id
+			JOIN keywords k ON k.id = fk.keyword_id AND k.deleted_at IS NULL
+			WHERE f.deleted_at IS NULL AND ` + where + `
+			GROUP BY 1 ORDER BY count DESC, value LIMIT ?`},
+	}
+
+	for _, fq := range queries {
+		if err := s.db.WithContext(ctx).Raw(fq.sql, filterArgs(args, nil, facetLimit)...).Scan(fq.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"sort"
+)
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60 is the value from the original RRF paper.
+const rrfK = 60
+
+// Names of the ranking signals fused by Search.
+const (
+	SignalContent  = "content"
+	SignalKeywords = "keywords"
+	SignalFileName = "filename"
+	SignalFuzzy    = "fuzzy"
+	SignalVector   = "vector"
+)
+
+// Ranked is one entry of a single signal's ranking, best first.
+type Ranked struct {
+	FileIndexID uint
+	Score       float64
+}
+
+// SignalScore records how a single signal ranked a file.
+type SignalScore struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+}
+
+// Fused is a file's combined reciprocal rank fusion score with the contributing signals.
+type Fused struct {
+	FileIndexID uint
+	Score       float64
+	Signals     map[string]SignalScore
+}
+
+// fuse merges per-signal rankings using reciprocal rank fusion, highest score first.
+func fuse(rankings map[string][]Ranked) []Fused {
+	byID := make(map[uint]*Fused)
+	var order []uint
+
+	for signal, ranking := range rankings {
+		for i, r := range ranking {
+			f, ok := byID[r.FileIndexID]
+			if !ok {
+				f = &Fused{FileIndexID: r.FileIndexID, Signals: make(map[string]SignalScore)}
+				byID[r.FileIndexID] = f
+				order = append(order, r.FileIndexID)
+			}
+			rank := i + 1
+			f.Score += 1.0 / float64(rrfK+rank)
+			f.Signals[signal] = SignalScore{Rank: rank, Score: r.Score}
+		}
+	}
+
+	fused := make([]Fused, 0, len(order))
+	for _, id := range order {
+		fused = append(fused, *byID[id])
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].FileIndexID < fused[j].FileIndexID
+	})
+
+	return fused
+}
@@ -0,0 +1,29 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuse(t *testing.T) {
+	rankings := map[string][]Ranked{
+		SignalKeywords: {{FileIndexID: 1, Score: 0.9}, {FileIndexID: 2, Score: 0.5}},
+		SignalFileName: {{FileIndexID: 2, Score: 0.8}, {FileIndexID: 3, Score: 0.4}},
+	}
+
+	fused := fuse(rankings)
+
+	assert.Len(t, fused, 3)
+	assert.Equal(t, uint(2), fused[0].FileIndexID, "file found by both signals should rank first")
+	assert.Equal(t, SignalScore{Rank: 2, Score: 0.5}, fused[0].Signals[SignalKeywords])
+	assert.Equal(t, SignalScore{Rank: 1, Score: 0.8}, fused[0].Signals[SignalFileName])
+	assert.InDelta(t, 1.0/62+1.0/61, fused[0].Score, 1e-9)
+	assert.Equal(t, uint(1), fused[1].FileIndexID)
+	assert.Equal(t, uint(3), fused[2].FileIndexID)
+}
+
+func TestFuse_Empty(t *testing.T) {
+	assert.Empty(t, fuse(nil))
+	assert.Empty(t, fuse(map[string][]Ranked{SignalVector: nil}))
+}
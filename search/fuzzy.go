@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"prabandh/pkg/sqlutil"
+
+	"gorm.io/gorm"
+)
+
+// DefaultSimilarityThreshold matches pg_trgm's own default for the % operator.
+const DefaultSimilarityThreshold = 0.3
+
+// setThresholds sets pg_trgm's similarity thresholds for the current transaction so the
+// trigram operators used by fuzzy queries (and their GIN indexes) honour threshold.
+func setThresholds(tx *gorm.DB, threshold float64) error {
+	return tx.Exec(
+		"SELECT set_config('pg_trgm.similarity_threshold', ?, true), set_config('pg_trgm.word_similarity_threshold', ?, true)",
+		threshold, threshold,
+	).Error
+}
+
+// rankFuzzy ranks matching files by the best trigram similarity between text and
+// either the file name or one of its keywords, tolerating typos.
+func (s *Searcher) rankFuzzy(ctx context.Context, text, where string, args []interface{}) ([]Ranked, error) {
+	var ranked []Ranked
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id,
+			GREATEST(word_similarity(?, f.file_name), COALESCE(MAX(similarity(fs.summary_keyword, ?)), 0)) AS score
+		FROM file_indices f
+		LEFT JOIN file_summaries fs ON fs.file_index_id = f.id AND fs.deleted_at IS NULL AND fs.summary_keyword % ?
+		WHERE f.deleted_at IS NULL AND (? <% f.file_name OR fs.id IS NOT NULL)
+			AND `+where+`
+		GROUP BY f.id, f.file_name
+		ORDER BY score DESC, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{text, text, text, text}, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
+
+// suggest returns "did you mean" rewrites of the query in which terms that match
+// no file name or keyword are replaced by the most similar known keyword.
+func (s *Searcher) suggest(ctx context.Context, q *Query) ([]string, error) {
+	suggestion := q.input
+	changed := false
+
+	for _, term := range q.rankedTerms() {
+		var known int64
+		err := s.db.WithContext(ctx).Raw(`
+			SELECT
+				(SELECT COUNT(*) FROM file_summaries WHERE deleted_at IS NULL AND LOWER(summary_keyword) = LOWER(?)) +
+				(SELECT COUNT(*) FROM (SELECT 1 FROM file_indices WHERE deleted_at IS NULL AND file_name ILIKE ? LIMIT 1) n)`,
+			term, "%"+sqlutil.EscapeLike(term)+"%").Scan(&known).Error
+		if err != nil {
+			return nil, err
+		}
+		if known > 0 {
+			continue
+		}
+
+		var best []string
+		err = s.db.WithContext(ctx).Raw(`
+			SELECT summary_keyword
+			FROM file_summaries
+			WHERE deleted_at IS NULL AND summary_keyword % ?
+			GROUP BY summary_keyword
+			ORDER BY similarity(summary_keyword, ?) DESC, summary_keyword
+			LIMIT 1`, term, term).Scan(&best).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(best) == 0 || strings.EqualFold(best[0], term) {
+			continue
+		}
+
+		replacement := best[0]
+		if strings.Contains(replacement, " ") {
+			replacement = `"` + replacement + `"`
+		}
+		pattern := regexp.MustCompile(`(?i)"?\b` + regexp.QuoteMeta(term) + `\b"?`)
+		suggestion = pattern.ReplaceAllLiteralString(suggestion, replacement)
+		changed = true
+	}
+
+	if !changed {
+		return []string{}, nil
+	}
+	return []string{suggestion}, nil
+}
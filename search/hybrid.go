@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"prabandh/models"
+)
+
+// candidateLimit bounds how many rows each signal contributes before fusion.
+const candidateLimit = 100
+
+// HybridResult is a file returned by Search with the per-signal scores that produced its rank.
+type HybridResult struct {
+	models.FileIndex
+	Score   float64                `json:"score"`
+	Signals map[string]SignalScore `json:"signals"`
+}
+
+// rank fuses content and keyword full-text rank, filename trigram similarity,
+// typo-tolerant similarity for fuzzy queries and, when an embedding model is
+// reachable, vector similarity between vector, the embedding of the free text
+// of q, and file embeddings; a nil vector leaves that signal out. It returns
+// every ranked candidate, best first, together with the names of the signals
+// that contributed.
+func (s *Searcher) rank(ctx context.Context, q *Query, vector models.Vector) ([]Fused, []string, error) {
+	text := q.Text()
+	if text == "" {
+		return nil, []string{}, nil
+	}
+	// Bare terms are ranked rather than required, so semantic matches survive
+	where, args := q.rankFilter()
+
+	rankings := make(map[string][]Ranked)
+
+	content, err := s.rankContent(ctx, text, where, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	rankings[SignalContent] = content
+
+	keywords, err := s.rankKeywords(ctx, text, where, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	rankings[SignalKeywords] = keywords
+
+	fileNames, err := s.rankFileNames(ctx, text, where, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	rankings[SignalFileName] = fileNames
+
+	if q.fuzzy {
+		fuzzy, err := s.rankFuzzy(ctx, text, where, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		rankings[SignalFuzzy] = fuzzy
+	}
+
+	// Vector similarity is best effort: the embedding model may be unavailable
+	if vector != nil {
+		if vectors, err := s.rankVectors(ctx, vector, where, args); err == nil {
+			rankings[SignalVector] = vectors
+		}
+	}
+
+	signals := make([]string, 0, len(rankings))
+	for _, name := range []string{SignalContent, SignalKeywords, SignalFileName, SignalFuzzy, SignalVector} {
+		if _, ok := rankings[name]; ok {
+			signals = append(signals, name)
+		}
+	}
+
+	return fuse(rankings), signals, nil
+}
+
+// rankKeywords ranks matching files by full-text match of their keywords against any word of text.
+func (s *Searcher) rankKeywords(ctx context.Context, text, where string, args []interface{}) ([]Ranked, error) {
+	var ranked []Ranked
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id, SUM(ts_rank(multilingual_tsvector(fs.summary_keyword), q)) AS score
+		FROM file_summaries fs
+		JOIN file_indices f ON f.id = fs.file_index_id,
+			multilingual_websearch_query(?) q
+		WHERE fs.deleted_at IS NULL AND f.deleted_at IS NULL
+			AND multilingual_tsvector(fs.summary_keyword) @@ q
+			AND `+where+`
+		GROUP BY f.id
+		ORDER BY score DESC, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{anyTerm(text)}, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
+
+// rankFileNames ranks matching files by trigram word similarity between text and the file name.
+func (s *Searcher) rankFileNames(ctx context.Context, text, where string, args []interface{}) ([]Ranked, error) {
+	var ranked []Ranked
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id, word_similarity(?, f.file_name) AS score
+		FROM file_indices f
+		WHERE f.deleted_at IS NULL AND ? <% f.file_name
+			AND `+where+`
+		ORDER BY score DESC, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{text, text}, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
+
+// embed returns the embedding of text for vector ranking, or nil if there is
+// no text or the embedding model is unavailable. It calls the model server,
+// so callers run it before opening a transaction rather than inside one.
+func (s *Searcher) embed(ctx context.Context, text string) models.Vector {
+	if s.provider == nil || text == "" {
+		return nil
+	}
+	embedding, err := s.provider.Embed(ctx, text)
+	if err != nil {
+		return nil
+	}
+	return models.Vector(embedding)
+}
+
+// rankVectors ranks matching files by cosine similarity between vector and file embeddings.
+func (s *Searcher) rankVectors(ctx context.Context, vector models.Vector, where string, args []interface{}) ([]Ranked, error) {
+	var ranked []Ranked
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id, 1 - (e.embedding <=> ?::vector) AS score
+		FROM file_embeddings e
+		JOIN file_indices f ON f.id = e.file_index_id
+		WHERE e.deleted_at IS NULL AND f.deleted_at IS NULL
+			AND `+where+`
+		ORDER BY e.embedding <=> ?::vector, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{vector}, vector, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
+
+// unranked returns n files matching the filter that are not in fused, most
+// recently modified first, skipping the first offset of them.
+func (s *Searcher) unranked(ctx context.Context, where string, args []interface{}, fused []Fused, offset, n int) ([]Fused, error) {
+	exclude := []uint{0}
+	for _, f := range fused {
+		exclude = append(exclude, f.FileIndexID)
+	}
+
+	var ids []uint
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.id
+		FROM file_indices f
+		WHERE f.deleted_at IS NULL AND f.id NOT IN ?
+			AND `+where+`
+		ORDER BY f.modified_date DESC, f.id
+		LIMIT ? OFFSET ?`, filterArgs(args, []interface{}{exclude}, n, offset)...).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	unranked := make([]Fused, len(ids))
+	for i, id := range ids {
+		unranked[i] = Fused{FileIndexID: id, Signals: map[string]SignalScore{}}
+	}
+	return unranked, nil
+}
+
+// loadFused fetches the files behind fused rankings, preserving their order.
+func (s *Searcher) loadFused(ctx context.Context, fused []Fused) ([]HybridResult, error) {
+	if len(fused) == 0 {
+		return []HybridResult{}, nil
+	}
+
+	ids := make([]uint, len(fused))
+	for i, f := range fused {
+		ids[i] = f.FileIndexID
+	}
+
+	var files []models.FileIndex
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&files).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.FileIndex, len(files))
+	for _, file := range files {
+		byID[file.ID] = file
+	}
+
+	results := make([]HybridResult, 0, len(fused))
+	for _, f := range fused {
+		file, ok := byID[f.FileIndexID]
+		if !ok {
+			continue
+		}
+		results = append(results, HybridResult{
+			FileIndex: file,
+			Score:     f.Score,
+			Signals:   f.Signals,
+		})
+	}
+	return results, nil
+}
+
+// anyTerm rewrites a free-text query so websearch_to_tsquery matches any of its words.
+func anyTerm(query string) string {
+	return strings.Join(strings.Fields(query), " or ")
+}
+
+// filterArgs orders placeholder arguments for a query whose filter condition
+// sits between the before and after placeholders.
+func filterArgs(args []interface{}, before []interface{}, after ...interface{}) []interface{} {
+	out := append(before, args...)
+	return append(out, after...)
+}
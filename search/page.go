@@ -0,0 +1,308 @@
+package search
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"prabandh/models"
+
+	"gorm.io/gorm"
+)
+
+// Sort orders accepted by Search.
+const (
+	SortRelevance = "relevance"
+	SortModified  = "modified"
+	SortSize      = "size"
+	SortName      = "name"
+)
+
+// sortColumns maps keyset sort orders to their file_indices column and default direction.
+var sortColumns = map[string]struct {
+	column string
+	desc   bool
+}{
+	SortModified: {"f.modified_date", true},
+	SortSize:     {"f.size", true},
+	SortName:     {"f.file_name", false},
+}
+
+// ErrInvalidOptions is returned by Search for unknown sort orders or malformed cursors.
+var ErrInvalidOptions = errors.New("invalid search options")
+
+// Options control ordering and pagination of Search results.
+type Options struct {
+	Limit  int
+	Sort   string // One of the Sort constants; defaults to SortRelevance
+	Order  string // "asc" or "desc"; defaults depend on Sort
+	Cursor string // NextCursor from the previous page
+	Facets bool   // Compute facet counts (first page only)
+
+	Fuzzy     bool    // Tolerate typos in name and keyword terms using trigram similarity
+	Threshold float64 // Minimum trigram similarity in (0, 1]; defaults to DefaultSimilarityThreshold
+}
+
+// Page is one page of search results.
+type Page struct {
+	Results     []HybridResult `json:"results"`
+	Signals     []string       `json:"signals"`
+	NextCursor  string         `json:"next_cursor,omitempty"`
+	Facets      *Facets        `json:"facets,omitempty"`
+	Suggestions []string       `json:"suggestions,omitempty"`
+}
+
+// cursor is the decoded form of Page.NextCursor. Relevance pages resume at an
+// offset into the ranking; other sorts resume after the last (value, id) seen.
+type cursor struct {
+	Sort   string `json:"s"`
+	Order  string `json:"o"`
+	Offset int    `json:"n,omitempty"`
+	Value  string `json:"v,omitempty"`
+	ID     uint   `json:"i,omitempty"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidOptions)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidOptions)
+	}
+	return c, nil
+}
+
+// Search returns one page of files matching q in the requested order.
+func (s *Searcher) Search(ctx context.Context, q *Query, opts Options) (*Page, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	if opts.Sort == "" {
+		opts.Sort = SortRelevance
+	}
+	if opts.Sort != SortRelevance {
+		if _, ok := sortColumns[opts.Sort]; !ok {
+			return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidOptions, opts.Sort)
+		}
+	}
+	if opts.Threshold <= 0 || opts.Threshold > 1 {
+		opts.Threshold = DefaultSimilarityThreshold
+	}
+	if opts.Order == "" {
+		opts.Order = "desc"
+		if sc, ok := sortColumns[opts.Sort]; ok && !sc.desc {
+			opts.Order = "asc"
+		}
+	}
+	if opts.Order != "asc" && opts.Order != "desc" {
+		return nil, fmt.Errorf("%w: unknown order %q", ErrInvalidOptions, opts.Order)
+	}
+
+	var after cursor
+	if opts.Cursor != "" {
+		var err error
+		if after, err = decodeCursor(opts.Cursor); err != nil {
+			return nil, err
+		}
+		if after.Sort != opts.Sort || after.Order != opts.Order {
+			return nil, fmt.Errorf("%w: cursor does not match sort order", ErrInvalidOptions)
+		}
+	}
+
+	if opts.Fuzzy {
+		fuzzy, err := q.Fuzzy()
+		if err != nil {
+			return nil, err
+		}
+		q = fuzzy
+	}
+
+	// The query is embedded before the transaction opens, so a connection is
+	// not held idle while the model server answers
+	var vector models.Vector
+	if opts.Sort == SortRelevance {
+		vector = s.embed(ctx, q.Text())
+	}
+
+	var page *Page
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := setThresholds(tx, opts.Threshold); err != nil {
+			return err
+		}
+
+		var err error
+		page, err = (&Searcher{db: tx, provider: s.provider}).page(ctx, q, opts, after, vector)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// page runs a validated search, adding facets and suggestions to the first
+// page. vector is the embedding of q's free text, if any.
+func (s *Searcher) page(ctx context.Context, q *Query, opts Options, after cursor, vector models.Vector) (*Page, error) {
+	var page *Page
+	var err error
+	if opts.Sort == SortRelevance {
+		page, err = s.relevancePage(ctx, q, opts, after.Offset, vector)
+	} else {
+		page, err = s.keysetPage(ctx, q, opts, after)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Cursor != "" {
+		return page, nil
+	}
+
+	if opts.Facets {
+		if page.Facets, err = s.facets(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+
+	// Offer corrections when typos are expected or nothing matched
+	if opts.Fuzzy || len(page.Results) == 0 {
+		if page.Suggestions, err = s.suggest(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// relevancePage slices the fused ranking at offset, continuing into unranked filter matches.
+func (s *Searcher) relevancePage(ctx context.Context, q *Query, opts Options, offset int, vector models.Vector) (*Page, error) {
+	fused, signals, err := s.rank(ctx, q, vector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	want := opts.Limit + 1
+
+	var window []Fused
+	if offset < len(fused) {
+		end := offset + want
+		if end > len(fused) {
+			end = len(fused)
+		}
+		window = append(window, fused[offset:end]...)
+	}
+
+	if len(window) < want {
+		where, args := q.Where()
+		skip := offset - len(fused)
+		if skip < 0 {
+			skip = 0
+		}
+		unranked, err := s.unranked(ctx, where, args, fused, skip, want-len(window))
+		if err != nil {
+			return nil, err
+		}
+		window = append(window, unranked...)
+	}
+
+	page := &Page{Signals: signals}
+	if len(window) > opts.Limit {
+		window = window[:opts.Limit]
+		page.NextCursor = encodeCursor(cursor{Sort: opts.Sort, Order: opts.Order, Offset: offset + opts.Limit})
+	}
+
+	if page.Results, err = s.loadFused(ctx, window); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// keysetPage orders matches by a file column, resuming after the cursor's (value, id).
+func (s *Searcher) keysetPage(ctx context.Context, q *Query, opts Options, after cursor) (*Page, error) {
+	where, args := q.Where()
+	sc := sortColumns[opts.Sort]
+
+	cmp, dir := ">", "ASC"
+	if opts.Order == "desc" {
+		cmp, dir = "<", "DESC"
+	}
+
+	tx := s.db.WithContext(ctx).Table("file_indices f").
+		Select("f.*").
+		Where("f.deleted_at IS NULL").
+		Where(where, args...)
+
+	if after.ID != 0 {
+		value, err := parseCursorValue(opts.Sort, after.Value)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(fmt.Sprintf("(%s, f.id) %s (?, ?)", sc.column, cmp), value, after.ID)
+	}
+
+	var results []HybridResult
+	err := tx.Order(fmt.Sprintf("%s %s, f.id %s", sc.column, dir, dir)).
+		Limit(opts.Limit + 1).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Results: results, Signals: []string{}}
+	if len(results) > opts.Limit {
+		page.Results = results[:opts.Limit]
+		last := page.Results[len(page.Results)-1]
+		page.NextCursor = encodeCursor(cursor{
+			Sort:  opts.Sort,
+			Order: opts.Order,
+			Value: cursorValue(opts.Sort, last),
+			ID:    last.ID,
+		})
+	}
+	for i := range page.Results {
+		page.Results[i].Signals = map[string]SignalScore{}
+	}
+	return page, nil
+}
+
+// cursorValue serialises the sort key of a result for a keyset cursor.
+func cursorValue(sort string, r HybridResult) string {
+	switch sort {
+	case SortModified:
+		return r.ModifiedDate.Format(time.RFC3339Nano)
+	case SortSize:
+		return strconv.FormatInt(r.Size, 10)
+	}
+	return r.FileName
+}
+
+func parseCursorValue(sort, value string) (interface{}, error) {
+	switch sort {
+	case SortModified:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidOptions)
+		}
+		return t, nil
+	case SortSize:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidOptions)
+		}
+		return n, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"context"
+	"prabandh/models"
+)
+
+// passageOptions configures the ts_headline excerpts returned by Passages.
+// Fragments are longer than search snippets so they carry enough context to
+// answer questions from.
+const passageOptions = "StartSel=\"\", StopSel=\"\", MaxFragments=4, MinWords=15, MaxWords=60, FragmentDelimiter=\" ... \""
+
+// Passage is a file relevant to a question together with excerpts of its
+// extracted content.
+type Passage struct {
+	models.FileIndex
+	Score   float64 `json:"score"`
+	Excerpt string  `json:"excerpt"`
+}
+
+// Passages retrieves the files most relevant to a natural-language question,
+// fusing content and keyword full-text rank with vector similarity when an
+// embedding model is reachable, and returns excerpts of their content around
+// the matched words. With excludeSensitive, files flagged as sensitive are
+// left out.
+func (s *Searcher) Passages(ctx context.Context, question string, limit int, excludeSensitive bool) ([]Passage, error) {
+	rankings := make(map[string][]Ranked)
+
+	where := "TRUE"
+	if excludeSensitive {
+		where = "NOT f.sensitive"
+	}
+
+	content, err := s.rankContent(ctx, question, where, nil)
+	if err != nil {
+		return nil, err
+	}
+	rankings[SignalContent] = content
+
+	keywords, err := s.rankKeywords(ctx, question, where, nil)
+	if err != nil {
+		return nil, err
+	}
+	rankings[SignalKeywords] = keywords
+
+	// Vector similarity is best effort: the embedding model may be unavailable
+	if vector := s.embed(ctx, question); vector != nil {
+		if vectors, err := s.rankVectors(ctx, vector, where, nil); err == nil {
+			rankings[SignalVector] = vectors
+		}
+	}
+
+	fused := fuse(rankings)
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+
+	results, err := s.loadFused(ctx, fused)
+	if err != nil || len(results) == 0 {
+		return []Passage{}, err
+	}
+
+	ids := make([]uint, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+
+	var excerpts []struct {
+		FileIndexID uint
+		Excerpt     string
+	}
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id, ts_headline(c.search_config, c.content, q, ?) AS excerpt
+		FROM file_indices f
+		JOIN file_contents c ON c.hash = f.hash AND c.deleted_at IS NULL,
+			multilingual_websearch_query(?) q
+		WHERE f.id IN ?`, passageOptions, anyTerm(question), ids).Scan(&excerpts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]string, len(excerpts))
+	for _, e := range excerpts {
+		byID[e.FileIndexID] = e.Excerpt
+	}
+
+	passages := make([]Passage, len(results))
+	for i, r := range results {
+		passages[i] = Passage{
+			FileIndex: r.FileIndex,
+			Score:     r.Score,
+			Excerpt:   byID[r.ID],
+		}
+	}
+	return passages, nil
+}
@@ -0,0 +1,692 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"prabandh/keywords"
+	"prabandh/pkg/entities"
+	"prabandh/pkg/sqlutil"
+)
+
+// Query is a parsed search query such as
+//
+//	ext:pdf size:>5MB modified:2024-01..2024-06 path:~/work (invoice OR receipt) -draft
+//
+// Terms are combined with AND (implicit), OR and NOT (or a leading '-'), and
+// can be grouped with parentheses or quoted as phrases. Supported fields are
+// ext, size, modified, created, path, name, keyword (kw) and content; bare
+// terms match the file name, keywords or content. The entity fields person,
+// org, place and entity (any type) match files mentioning a named entity,
+// while date and amount take ranges over the dates and amounts they mention,
+// e.g. amount:>1000 date:2024-03. Amounts are compared regardless of currency.
+type Query struct {
+	input     string
+	root      node
+	where     string
+	args      []interface{}
+	rankWhere string
+	rankArgs  []interface{}
+	fuzzy     bool
+}
+
+type node interface{}
+
+type andNode struct{ children []node }
+
+type orNode struct{ children []node }
+
+type notNode struct{ child node }
+
+type termNode struct {
+	field string
+	value string
+}
+
+// ParseQuery parses input into a Query, validating field values.
+func ParseQuery(input string) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text())
+	}
+
+	return newQuery(input, root, false)
+}
+
+// PathQuery returns a Query for files whose path contains path, as the path
+// field matches, without parsing path as query syntax. An empty path matches
+// every file.
+func PathQuery(path string) (*Query, error) {
+	if strings.TrimSpace(path) == "" {
+		return newQuery(path, nil, false)
+	}
+	return newQuery(path, &termNode{field: "path", value: path}, false)
+}
+
+func newQuery(input string, root node, fuzzy bool) (*Query, error) {
+	q := &Query{input: input, root: root, where: "TRUE", rankWhere: "TRUE", fuzzy: fuzzy}
+	if root != nil {
+		c := &compiler{fuzzy: fuzzy}
+		where, err := c.compile(root)
+		if err != nil {
+			return nil, err
+		}
+		q.where, q.args = where, c.args
+
+		c = &compiler{fuzzy: fuzzy, rankOnly: true}
+		if q.rankWhere, err = c.compile(root); err != nil {
+			return nil, err
+		}
+		q.rankArgs = c.args
+	}
+	return q, nil
+}
+
+// Fuzzy returns a copy of q whose name and keyword terms also match by trigram
+// similarity, tolerating typos such as "reciept". Searches run with
+// Options.Fuzzy apply this automatically.
+func (q *Query) Fuzzy() (*Query, error) {
+	if q.fuzzy {
+		return q, nil
+	}
+	return newQuery(q.input, q.root, true)
+}
+
+// Empty reports whether the query has no terms at all.
+func (q *Query) Empty() bool {
+	return q.root == nil
+}
+
+// Where returns a SQL condition over file_indices aliased as "f" with its arguments.
+func (q *Query) Where() (string, []interface{}) {
+	return q.where, q.args
+}
+
+// rankFilter is Where without the bare terms that Text ranks by, so that
+// signals such as vector similarity can rank files those terms do not match
+// literally.
+func (q *Query) rankFilter() (string, []interface{}) {
+	return q.rankWhere, q.rankArgs
+}
+
+// Text returns the free-text terms used for relevance ranking: bare, name,
+// keyword and content terms that are not negated.
+func (q *Query) Text() string {
+	return strings.Join(q.rankedTerms(), " ")
+}
+
+func (q *Query) rankedTerms() []string {
+	var terms []string
+	collectText(q.root, &terms)
+	return terms
+}
+
+func collectText(n node, terms *[]string) {
+	switch n := n.(type) {
+	case *andNode:
+		for _, child := range n.children {
+			collectText(child, terms)
+		}
+	case *orNode:
+		for _, child := range n.children {
+			collectText(child, terms)
+		}
+	case *termNode:
+		switch n.field {
+		case "", "name", "keyword", "content":
+			*terms = append(*terms, n.value)
+		}
+	}
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokTerm tokenKind = iota
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	field string
+	value string
+}
+
+func (t token) text() string {
+	switch t.kind {
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	case tokLParen:
+		return "("
+	case tokRParen:
+		return ")"
+	}
+	if t.field != "" {
+		return t.field + ":" + t.value
+	}
+	return t.value
+}
+
+// fieldAliases maps accepted field names to their canonical form.
+var fieldAliases = map[string]string{
+	"ext":       "ext",
+	"extension": "ext",
+	"size":      "size",
+	"modified":  "modified",
+	"created":   "created",
+	"path":      "path",
+	"name":      "name",
+	"keyword":   "keyword",
+	"kw":        "keyword",
+	"content":   "content",
+
+	"person":       "person",
+	"org":          "organization",
+	"organization": "organization",
+	"organisation": "organization",
+	"place":        "place",
+	"entity":       "entity",
+	"date":         "date",
+	"amount":       "amount",
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen})
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] != ' ':
+			tokens = append(tokens, token{kind: tokNot})
+			i++
+		case r == '"':
+			value, next, err := readQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokTerm, value: value})
+			i = next
+		default:
+			start := i
+			for i < len(runes) && !strings.ContainsRune(" \t\n()\"", runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+
+			switch word {
+			case "AND":
+				tokens = append(tokens, token{kind: tokAnd})
+				continue
+			case "OR":
+				tokens = append(tokens, token{kind: tokOr})
+				continue
+			case "NOT":
+				tokens = append(tokens, token{kind: tokNot})
+				continue
+			}
+
+			tok := token{kind: tokTerm, value: word}
+			if name, value, ok := strings.Cut(word, ":"); ok {
+				if field, known := fieldAliases[strings.ToLower(name)]; known {
+					tok.field = field
+					tok.value = value
+					// Allow quoted field values, e.g. path:"~/my docs"
+					if value == "" && i < len(runes) && runes[i] == '"' {
+						quoted, next, err := readQuoted(runes, i)
+						if err != nil {
+							return nil, err
+						}
+						tok.value = quoted
+						i = next
+					}
+					if tok.value == "" {
+						return nil, fmt.Errorf("missing value for %s:", name)
+					}
+				}
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return tokens, nil
+}
+
+// readQuoted reads a double-quoted string starting at runes[start] and returns it with the index after the closing quote.
+func readQuoted(runes []rune, start int) (string, int, error) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end >= len(runes) {
+		return "", 0, fmt.Errorf("unterminated quote")
+	}
+	return string(runes[start+1 : end]), end + 1, nil
+}
+
+// Parser
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	var children []node
+	for {
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			children = append(children, child)
+		}
+
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			break
+		}
+		p.pos++
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	return &orNode{children: children}, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	var children []node
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokRParen {
+			break
+		}
+		if tok.kind == tokAnd {
+			p.pos++
+			continue
+		}
+
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	return &andNode{children: children}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	switch tok.kind {
+	case tokNot:
+		p.pos++
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{child: child}, nil
+	case tokLParen:
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next.kind != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		if inner == nil {
+			return nil, fmt.Errorf("empty parentheses")
+		}
+		return inner, nil
+	case tokTerm:
+		p.pos++
+		return &termNode{field: tok.field, value: tok.value}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q", tok.text())
+}
+
+// SQL compilation
+
+// compiler turns a query tree into a SQL condition, collecting placeholder
+// arguments. In fuzzy mode name and keyword terms also match by trigram
+// similarity, using the pg_trgm thresholds set on the connection. In rankOnly
+// mode bare terms that are not negated match every file, as they are ranked
+// instead.
+type compiler struct {
+	args     []interface{}
+	fuzzy    bool
+	rankOnly bool
+	negated  bool
+}
+
+func (c *compiler) compile(n node) (string, error) {
+	switch n := n.(type) {
+	case *andNode:
+		return c.compileGroup(n.children, " AND ")
+	case *orNode:
+		return c.compileGroup(n.children, " OR ")
+	case *notNode:
+		c.negated = !c.negated
+		inner, err := c.compile(n.child)
+		c.negated = !c.negated
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	case *termNode:
+		return c.compileTerm(n)
+	}
+	return "", fmt.Errorf("unknown query node %T", n)
+}
+
+func (c *compiler) compileGroup(children []node, sep string) (string, error) {
+	parts := make([]string, len(children))
+	for i, child := range children {
+		sql, err := c.compile(child)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = sql
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+// Keyword terms match either the raw keywords produced for a file or its
+// canonical keywords, by key or through a synonym.
+const (
+	canonicalKeywordCondition = "EXISTS (SELECT 1 FROM file_keywords fk JOIN keywords k ON k.id = fk.keyword_id WHERE fk.file_index_id = f.id AND (k.key = ? OR k.id IN (SELECT keyword_id FROM keyword_synonyms WHERE deleted_at IS NULL AND alias = ?)))"
+	keywordCondition          = "(EXISTS (SELECT 1 FROM file_summaries s WHERE s.file_index_id = f.id AND s.deleted_at IS NULL AND multilingual_tsvector(s.summary_keyword) @@ multilingual_phrase_query(?)) OR " + canonicalKeywordCondition + ")"
+	fuzzyKeywordCondition     = "(EXISTS (SELECT 1 FROM file_summaries s WHERE s.file_index_id = f.id AND s.deleted_at IS NULL AND (multilingual_tsvector(s.summary_keyword) @@ multilingual_phrase_query(?) OR s.summary_keyword % ?)) OR " + canonicalKeywordCondition + ")"
+	contentCondition          = "EXISTS (SELECT 1 FROM file_contents c WHERE c.hash = f.hash AND c.deleted_at IS NULL AND c.content_tsv @@ multilingual_phrase_query(?))"
+)
+
+// Entity terms match the normalised value of an entity of the file.
+const (
+	entityCondition      = "EXISTS (SELECT 1 FROM entities e WHERE e.file_index_id = f.id AND e.deleted_at IS NULL AND e.type = ? AND e.value ILIKE ?)"
+	anyEntityCondition   = "EXISTS (SELECT 1 FROM entities e WHERE e.file_index_id = f.id AND e.deleted_at IS NULL AND e.value ILIKE ?)"
+	entityRangeCondition = "EXISTS (SELECT 1 FROM entities e WHERE e.file_index_id = f.id AND e.deleted_at IS NULL AND %s)"
+)
+
+func (c *compiler) compileTerm(t *termNode) (string, error) {
+	switch t.field {
+	case "ext":
+		ext := "." + strings.TrimPrefix(strings.ToLower(t.value), ".")
+		c.args = append(c.args, ext)
+		return "LOWER(f.extension) = ?", nil
+
+	case "size":
+		return compileRange("f.size", t.value, parseSizeBounds, &c.args)
+
+	case "modified":
+		return compileRange("f.modified_date", t.value, parseDateBounds, &c.args)
+
+	case "created":
+		return compileRange("f.created_date", t.value, parseDateBounds, &c.args)
+
+	case "path":
+		path, prefix := expandPath(t.value)
+		if prefix {
+			dir := strings.TrimSuffix(path, "/")
+			c.args = append(c.args, dir, sqlutil.EscapeLike(dir)+"/%")
+			return "(f.file_path = ? OR f.file_path LIKE ?)", nil
+		}
+		c.args = append(c.args, "%"+sqlutil.EscapeLike(path)+"%")
+		return "f.file_path ILIKE ?", nil
+
+	case "name":
+		return c.nameCondition(t.value), nil
+
+	case "keyword":
+		return c.keywordCondition(t.value), nil
+
+	case "content":
+		c.args = append(c.args, t.value)
+		return contentCondition, nil
+
+	case "person", "organization", "place":
+		c.args = append(c.args, t.field, "%"+sqlutil.EscapeLike(t.value)+"%")
+		return entityCondition, nil
+
+	case "entity":
+		c.args = append(c.args, "%"+sqlutil.EscapeLike(t.value)+"%")
+		return anyEntityCondition, nil
+
+	case "date":
+		cond, err := compileRange("e.date", t.value, parseDateBounds, &c.args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(entityRangeCondition, cond), nil
+
+	case "amount":
+		cond, err := compileRange("e.amount", t.value, parseAmountBounds, &c.args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(entityRangeCondition, cond), nil
+	}
+
+	if c.rankOnly && !c.negated {
+		return "TRUE", nil
+	}
+	name := c.nameCondition(t.value)
+	keyword := c.keywordCondition(t.value)
+	c.args = append(c.args, t.value)
+	return "(" + name + " OR " + keyword + " OR " + contentCondition + ")", nil
+}
+
+func (c *compiler) nameCondition(value string) string {
+	c.args = append(c.args, "%"+sqlutil.EscapeLike(value)+"%")
+	if c.fuzzy {
+		c.args = append(c.args, value)
+		return "(f.file_name ILIKE ? OR ? <% f.file_name)"
+	}
+	return "f.file_name ILIKE ?"
+}
+
+func (c *compiler) keywordCondition(value string) string {
+	key := keywords.Key(value)
+	if c.fuzzy {
+		c.args = append(c.args, value, value, key, key)
+		return fuzzyKeywordCondition
+	}
+	c.args = append(c.args, value, key, key)
+	return keywordCondition
+}
+
+// bounds is a half-open interval [lower, upper); nil ends are unbounded.
+type bounds struct {
+	lower interface{}
+	upper interface{}
+}
+
+// boundsParser turns a single value into the interval it denotes, e.g. "2024-01" covers all of January.
+type boundsParser func(string) (bounds, error)
+
+// compileRange handles the comparison forms shared by size and date fields:
+// "a..b", "a..", "..b", ">a", ">=a", "<a", "<=a" and a plain value.
+func compileRange(column, value string, parse boundsParser, args *[]interface{}) (string, error) {
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		var conds []string
+		if from != "" {
+			b, err := parse(from)
+			if err != nil {
+				return "", err
+			}
+			conds = append(conds, column+" >= ?")
+			*args = append(*args, b.lower)
+		}
+		if to != "" {
+			b, err := parse(to)
+			if err != nil {
+				return "", err
+			}
+			conds = append(conds, column+" < ?")
+			*args = append(*args, b.upper)
+		}
+		if len(conds) == 0 {
+			return "", fmt.Errorf("empty range %q", value)
+		}
+		return "(" + strings.Join(conds, " AND ") + ")", nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+		b, err := parse(strings.TrimPrefix(value, op))
+		if err != nil {
+			return "", err
+		}
+		switch op {
+		case ">=":
+			*args = append(*args, b.lower)
+			return column + " >= ?", nil
+		case "<=":
+			*args = append(*args, b.upper)
+			return column + " < ?", nil
+		case ">":
+			*args = append(*args, b.upper)
+			return column + " >= ?", nil
+		case "<":
+			*args = append(*args, b.lower)
+			return column + " < ?", nil
+		}
+		*args = append(*args, b.lower, b.upper)
+		return "(" + column + " >= ? AND " + column + " < ?)", nil
+	}
+
+	b, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	*args = append(*args, b.lower, b.upper)
+	return "(" + column + " >= ? AND " + column + " < ?)", nil
+}
+
+var sizePattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([kmgt]?)i?b?$`)
+
+var sizeUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// parseSizeBounds parses sizes such as "512", "10KB" or "1.5GiB" (1024-based).
+func parseSizeBounds(value string) (bounds, error) {
+	m := sizePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return bounds{}, fmt.Errorf("invalid size %q", value)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return bounds{}, fmt.Errorf("invalid size %q", value)
+	}
+	size := int64(n * sizeUnits[strings.ToLower(m[2])])
+	return bounds{lower: size, upper: size + 1}, nil
+}
+
+// parseAmountBounds parses amounts such as "1200", "1,200.50" or "$99" to the
+// cent; the currency is ignored.
+func parseAmountBounds(value string) (bounds, error) {
+	amount, _, ok := entities.ParseAmount(value)
+	if !ok {
+		return bounds{}, fmt.Errorf("invalid amount %q", value)
+	}
+	return bounds{lower: amount, upper: amount + 0.01}, nil
+}
+
+// parseDateBounds parses "2024", "2024-06" or "2024-06-15" into the year, month or day they cover.
+func parseDateBounds(value string) (bounds, error) {
+	layouts := []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	}
+
+	for _, l := range layouts {
+		if len(value) != len(l.layout) {
+			continue
+		}
+		start, err := time.ParseInLocation(l.layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		return bounds{lower: start, upper: start.AddDate(l.years, l.months, l.days)}, nil
+	}
+
+	return bounds{}, fmt.Errorf("invalid date %q (use YYYY, YYYY-MM or YYYY-MM-DD)", value)
+}
+
+// expandPath resolves a leading "~" and reports whether the path is anchored and should match as a prefix.
+func expandPath(path string) (string, bool) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path, filepath.IsAbs(path)
+}
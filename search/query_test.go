@@ -0,0 +1,138 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery_Fields(t *testing.T) {
+	q, err := ParseQuery(`ext:PDF size:>5MB invoice`)
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "(LOWER(f.extension) = ? AND f.size >= ? AND (f.file_name ILIKE ? OR "+keywordCondition+" OR "+contentCondition+"))", where)
+	assert.Equal(t, []interface{}{".pdf", int64(5<<20 + 1), "%invoice%", "invoice", "invoic", "invoic", "invoice"}, args)
+	assert.Equal(t, "invoice", q.Text())
+}
+
+func TestParseQuery_DateRange(t *testing.T) {
+	q, err := ParseQuery(`modified:2024-01..2024-06`)
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "(f.modified_date >= ? AND f.modified_date < ?)", where)
+	assert.Equal(t, []interface{}{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local),
+	}, args)
+	assert.Empty(t, q.Text())
+}
+
+func TestParseQuery_BooleanOperators(t *testing.T) {
+	q, err := ParseQuery(`(kw:tax OR kw:"annual report") NOT name:draft -ext:tmp`)
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "(("+keywordCondition+" OR "+keywordCondition+") AND NOT (f.file_name ILIKE ?) AND NOT (LOWER(f.extension) = ?))", where)
+	assert.Equal(t, []interface{}{"tax", "tax", "tax", "annual report", "annual report", "annual report", "%draft%", ".tmp"}, args)
+	assert.Equal(t, "tax annual report", q.Text(), "negated terms should not be ranked")
+}
+
+func TestParseQuery_PathPrefix(t *testing.T) {
+	q, err := ParseQuery(`path:/srv/work_files/`)
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "(f.file_path = ? OR f.file_path LIKE ?)", where)
+	assert.Equal(t, []interface{}{"/srv/work_files", `/srv/work\_files/%`}, args)
+}
+
+func TestParseQuery_Empty(t *testing.T) {
+	q, err := ParseQuery("   ")
+	require.NoError(t, err)
+	assert.True(t, q.Empty())
+
+	where, args := q.Where()
+	assert.Equal(t, "TRUE", where)
+	assert.Empty(t, args)
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, input := range []string{
+		`size:>lots`,
+		`modified:2024-13`,
+		`(invoice`,
+		`invoice)`,
+		`"unterminated`,
+		`ext:`,
+		`modified:..`,
+	} {
+		_, err := ParseQuery(input)
+		assert.Error(t, err, "expected error for %q", input)
+	}
+}
+
+func TestQuery_Fuzzy(t *testing.T) {
+	q, err := ParseQuery(`reciept ext:pdf`)
+	require.NoError(t, err)
+
+	fuzzy, err := q.Fuzzy()
+	require.NoError(t, err)
+
+	where, args := fuzzy.Where()
+	assert.Equal(t, "(((f.file_name ILIKE ? OR ? <% f.file_name) OR "+fuzzyKeywordCondition+" OR "+contentCondition+") AND LOWER(f.extension) = ?)", where)
+	assert.Equal(t, []interface{}{"%reciept%", "reciept", "reciept", "reciept", "reciept", "reciept", "reciept", ".pdf"}, args)
+
+	exact, _ := q.Where()
+	assert.NotContains(t, exact, "<%", "the original query should stay exact")
+}
+
+func TestParseQuery_Entities(t *testing.T) {
+	q, err := ParseQuery(`org:acme person:"Jane Doe" amount:>$1,000 date:2024-03`)
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "("+entityCondition+" AND "+entityCondition+
+		" AND EXISTS (SELECT 1 FROM entities e WHERE e.file_index_id = f.id AND e.deleted_at IS NULL AND e.amount >= ?)"+
+		" AND EXISTS (SELECT 1 FROM entities e WHERE e.file_index_id = f.id AND e.deleted_at IS NULL AND (e.date >= ? AND e.date < ?)))", where)
+	assert.Equal(t, []interface{}{
+		"organization", "%acme%",
+		"person", "%Jane Doe%",
+		1000.01,
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+	}, args)
+	assert.Empty(t, q.Text(), "entity terms should not be ranked")
+
+	_, err = ParseQuery(`amount:lots`)
+	assert.Error(t, err)
+}
+
+func TestQuery_RankFilterKeepsOnlyFieldFilters(t *testing.T) {
+	q, err := ParseQuery(`ext:pdf invoice -draft`)
+	require.NoError(t, err)
+
+	where, args := q.rankFilter()
+	assert.Equal(t, "(LOWER(f.extension) = ? AND TRUE AND NOT ((f.file_name ILIKE ? OR "+keywordCondition+" OR "+contentCondition+")))", where)
+	assert.Equal(t, []interface{}{".pdf", "%draft%", "draft", "draft", "draft", "draft"}, args)
+
+	full, _ := q.Where()
+	assert.Contains(t, full, "f.file_name ILIKE ? OR", "Where should still require bare terms")
+}
+
+func TestPathQuery(t *testing.T) {
+	q, err := PathQuery(`50% "off"`)
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "f.file_path ILIKE ?", where)
+	assert.Equal(t, []interface{}{`%50\% "off"%`}, args, "the path should be escaped, not parsed")
+	assert.Empty(t, q.Text())
+
+	q, err = PathQuery(" ")
+	require.NoError(t, err)
+	assert.True(t, q.Empty())
+}
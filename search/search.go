@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prabandh/llm"
+	"prabandh/models"
+
+	"gorm.io/gorm"
+)
+
+var errNoEmbeddings = errors.New("semantic search requires an embedding model")
+
+// Searcher runs ranked queries against the file index.
+type Searcher struct {
+	db       *gorm.DB
+	provider llm.Provider
+}
+
+func NewSearcher(db *gorm.DB, provider llm.Provider) *Searcher {
+	return &Searcher{
+		db:       db,
+		provider: provider,
+	}
+}
+
+// Result is a matched file together with its score for the query.
+type Result struct {
+	models.FileIndex
+	Similarity float64 `json:"similarity"`
+}
+
+// Semantic embeds the query and returns the nearest files by cosine similarity.
+func (s *Searcher) Semantic(ctx context.Context, query string, limit int) ([]Result, error) {
+	if s.provider == nil {
+		return nil, errNoEmbeddings
+	}
+
+	embedding, err := s.provider.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	vector := models.Vector(embedding)
+
+	var results []Result
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT f.*, 1 - (e.embedding <=> ?::vector) AS similarity
+		FROM file_embeddings e
+		JOIN file_indices f ON f.id = e.file_index_id
+		WHERE e.deleted_at IS NULL AND f.deleted_at IS NULL
+		ORDER BY e.embedding <=> ?::vector
+		LIMIT ?`, vector, vector, limit).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
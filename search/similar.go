@@ -0,0 +1,165 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"prabandh/models"
+	"prabandh/pkg/sqlutil"
+
+	"gorm.io/gorm"
+)
+
+// SimilarOptions restrict and size the results of Similar.
+type SimilarOptions struct {
+	Limit         int
+	SameDir       bool // Only files under the same IndexDir (or parent directory) as the target
+	SameExtension bool // Only files with the target's extension
+}
+
+// Similar ranks files that resemble the file with the given ID by fusing
+// IDF-weighted keyword overlap with, when both files have embeddings, vector
+// similarity. It returns gorm.ErrRecordNotFound if the file does not exist.
+func (s *Searcher) Similar(ctx context.Context, fileIndexID uint, opts SimilarOptions) ([]HybridResult, []string, error) {
+	var target models.FileIndex
+	if err := s.db.WithContext(ctx).First(&target, fileIndexID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	where, args, err := s.similarScope(ctx, target, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rankings := make(map[string][]Ranked)
+
+	keywords, err := s.rankSharedKeywords(ctx, target.ID, where, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	rankings[SignalKeywords] = keywords
+
+	vectors, err := s.rankNearestEmbeddings(ctx, target.ID, where, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vectors != nil {
+		rankings[SignalVector] = vectors
+	}
+
+	signals := make([]string, 0, len(rankings))
+	for _, name := range []string{SignalKeywords, SignalVector} {
+		if _, ok := rankings[name]; ok {
+			signals = append(signals, name)
+		}
+	}
+
+	fused := fuse(rankings)
+	if len(fused) > opts.Limit {
+		fused = fused[:opts.Limit]
+	}
+
+	results, err := s.loadFused(ctx, fused)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, signals, nil
+}
+
+// similarScope builds the condition over file_indices "f" that candidates must satisfy.
+func (s *Searcher) similarScope(ctx context.Context, target models.FileIndex, opts SimilarOptions) (string, []interface{}, error) {
+	conds := []string{"f.id <> ?"}
+	args := []interface{}{target.ID}
+
+	if opts.SameExtension {
+		conds = append(conds, "LOWER(f.extension) = LOWER(?)")
+		args = append(args, target.Extension)
+	}
+
+	if opts.SameDir {
+		var indexDirs []models.IndexDir
+		if err := s.db.WithContext(ctx).Find(&indexDirs).Error; err != nil {
+			return "", nil, err
+		}
+
+		// Prefer the most specific IndexDir containing the target
+		dir := ""
+		for _, indexDir := range indexDirs {
+			location := strings.TrimSuffix(indexDir.DirectoryLocation, "/")
+			if strings.HasPrefix(target.FilePath, location+"/") && len(location) > len(dir) {
+				dir = location
+			}
+		}
+		if dir == "" {
+			dir = filepath.Dir(target.FilePath)
+		}
+
+		conds = append(conds, "f.file_path LIKE ?")
+		args = append(args, sqlutil.EscapeLike(dir)+"/%")
+	}
+
+	return strings.Join(conds, " AND "), args, nil
+}
+
+// rankSharedKeywords ranks files by weighted Jaccard similarity of their canonical
+// keywords to the target's, weighting each keyword by its inverse document frequency
+// so that rare shared tags count for more than ubiquitous ones.
+func (s *Searcher) rankSharedKeywords(ctx context.Context, targetID uint, where string, args []interface{}) ([]Ranked, error) {
+	var ranked []Ranked
+	err := s.db.WithContext(ctx).Raw(`
+		WITH idf AS (
+			SELECT keyword_id,
+				1 + ln((SELECT GREATEST(COUNT(*), 1) FROM file_indices WHERE deleted_at IS NULL)::float / COUNT(*)) AS weight
+			FROM file_keywords
+			GROUP BY keyword_id
+		),
+		totals AS (
+			SELECT fk.file_index_id, SUM(idf.weight) AS total
+			FROM file_keywords fk
+			JOIN idf ON idf.keyword_id = fk.keyword_id
+			GROUP BY fk.file_index_id
+		),
+		shared AS (
+			SELECT fk.file_index_id, SUM(idf.weight) AS weight
+			FROM file_keywords fk
+			JOIN file_keywords t ON t.keyword_id = fk.keyword_id AND t.file_index_id = ?
+			JOIN idf ON idf.keyword_id = fk.keyword_id
+			GROUP BY fk.file_index_id
+		)
+		SELECT f.id AS file_index_id, sh.weight / (c.total + t.total - sh.weight) AS score
+		FROM shared sh
+		JOIN totals c ON c.file_index_id = sh.file_index_id
+		JOIN totals t ON t.file_index_id = ?
+		JOIN file_indices f ON f.id = sh.file_index_id
+		WHERE f.deleted_at IS NULL
+			AND `+where+`
+		ORDER BY score DESC, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{targetID, targetID}, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
+
+// rankNearestEmbeddings ranks files by cosine similarity to the target's embedding.
+// It returns nil without error when the target has no embedding.
+func (s *Searcher) rankNearestEmbeddings(ctx context.Context, targetID uint, where string, args []interface{}) ([]Ranked, error) {
+	var embedding models.FileEmbedding
+	err := s.db.WithContext(ctx).Where("file_index_id = ?", targetID).First(&embedding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := []Ranked{}
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT f.id AS file_index_id, 1 - (e.embedding <=> ?::vector) AS score
+		FROM file_embeddings e
+		JOIN file_indices f ON f.id = e.file_index_id
+		WHERE e.deleted_at IS NULL AND f.deleted_at IS NULL
+			AND `+where+`
+		ORDER BY e.embedding <=> ?::vector, f.id
+		LIMIT ?`, filterArgs(args, []interface{}{embedding.Embedding}, embedding.Embedding, candidateLimit)...).Scan(&ranked).Error
+	return ranked, err
+}
@@ -0,0 +1,115 @@
+package taxonomy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"prabandh/llm"
+	"prabandh/llm/prompt"
+	"prabandh/models"
+
+	"gorm.io/gorm"
+)
+
+// Classifier assigns files to a taxonomy category with the LLM.
+type Classifier struct {
+	db       *gorm.DB
+	provider llm.Provider
+	prompts  *prompt.Library
+}
+
+func NewClassifier(db *gorm.DB, provider llm.Provider, prompts *prompt.Library) *Classifier {
+	return &Classifier{
+		db:       db,
+		provider: provider,
+		prompts:  prompts,
+	}
+}
+
+// Classify asks the LLM for the category that best fits file and stores it
+// with the model's confidence. It does nothing, returning nil, when no
+// taxonomy is defined or the file's category was set by hand.
+func (c *Classifier) Classify(ctx context.Context, file models.FileIndex, content string) (*models.FileCategory, error) {
+	db := c.db.WithContext(ctx)
+	store := NewStore(db)
+
+	categories, err := store.Categories()
+	if err != nil || len(categories) == 0 {
+		return nil, err
+	}
+
+	var existing models.FileCategory
+	err = db.Where("file_index_id = ? AND source = ?", file.ID, SourceManual).First(&existing).Error
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	tmpl, ok := c.prompts.Get(prompt.Classify)
+	if !ok {
+		return nil, fmt.Errorf("prompt %q is not defined", prompt.Classify)
+	}
+
+	data := prompt.NewData(file, content)
+	for _, category := range categories {
+		data.Categories = append(data.Categories, prompt.Category{Path: category.Path, Description: category.Description})
+	}
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Category   string  `json:"category"`
+		Confidence float64 `json:"confidence"`
+	}
+	if _, err := c.provider.CompleteJSON(ctx, rendered, &response); err != nil {
+		return nil, err
+	}
+
+	category, ok := match(categories, response.Category)
+	if !ok {
+		return nil, fmt.Errorf("%w: model answered %q", ErrUnknownCategory, response.Category)
+	}
+
+	assignment := &models.FileCategory{
+		FileIndexID:   file.ID,
+		CategoryID:    category.ID,
+		Confidence:    min(max(response.Confidence, 0), 1),
+		Source:        SourceLLM,
+		PromptVersion: tmpl.ID(),
+	}
+	stored, err := store.assign(db, assignment)
+	if err != nil || !stored {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// match finds the category the model named, tolerating differences in case
+// and spacing, or a unique last segment on its own ("tax" for "finance/tax").
+func match(categories []models.Category, answer string) (models.Category, bool) {
+	path, err := NormalizePath(answer)
+	if err != nil {
+		return models.Category{}, false
+	}
+
+	var byLeaf []models.Category
+	for _, category := range categories {
+		if category.Path == path {
+			return category, true
+		}
+		if category.Path[strings.LastIndex(category.Path, "/")+1:] == path {
+			byLeaf = append(byLeaf, category)
+		}
+	}
+
+	if len(byLeaf) == 1 {
+		return byLeaf[0], true
+	}
+	return models.Category{}, false
+}
@@ -0,0 +1,206 @@
+package taxonomy
+
+import (
+	"errors"
+	"strings"
+
+	"prabandh/models"
+	"prabandh/pkg/sqlutil"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sources of a file's category.
+const (
+	SourceLLM    = "llm"
+	SourceManual = "manual"
+)
+
+var (
+	ErrInvalidPath     = errors.New("category path must have at least one non-empty segment")
+	ErrUnknownCategory = errors.New("unknown category")
+)
+
+// NormalizePath lowercases a category path and tidies its separators, so
+// " Finance / Invoices/" becomes "finance/invoices".
+func NormalizePath(path string) (string, error) {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Join(strings.Fields(strings.ToLower(segment)), " ")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return "", ErrInvalidPath
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// Store manages the taxonomy and the category assigned to each file.
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Categories lists every category, ordered by path.
+func (s *Store) Categories() ([]models.Category, error) {
+	var categories []models.Category
+	err := s.db.Order("path").Find(&categories).Error
+	return categories, err
+}
+
+// Add creates a category, or updates the description of an existing one.
+func (s *Store) Add(path, description string) (*models.Category, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	category := models.Category{Path: path, Description: strings.TrimSpace(description)}
+	err = s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "updated_at"}),
+	}).Create(&category).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// On conflict the returned ID may not be populated
+	if err := s.db.Where("path = ?", path).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Delete removes a category and unassigns its files.
+func (s *Store) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("category_id = ?", id).Delete(&models.FileCategory{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Delete(&models.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// Tree returns the taxonomy as a tree with direct and total file counts.
+func (s *Store) Tree() ([]*Node, error) {
+	var counts []categoryCount
+	err := s.db.Raw(`
+		SELECT c.id, c.path, c.description, COUNT(f.id) AS files
+		FROM categories c
+		LEFT JOIN file_categories fc ON fc.category_id = c.id AND fc.deleted_at IS NULL
+		LEFT JOIN file_indices f ON f.id = fc.file_index_id AND f.deleted_at IS NULL
+		WHERE c.deleted_at IS NULL
+		GROUP BY c.id
+		ORDER BY c.path`).Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return buildTree(counts), nil
+}
+
+// CategorizedFile is a file together with its category assignment.
+type CategorizedFile struct {
+	models.FileIndex
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// Files lists files in the category at path or any of its descendants, most
+// confident first.
+func (s *Store) Files(path string, limit, offset int) ([]CategorizedFile, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []CategorizedFile
+	err = s.db.Raw(`
+		SELECT f.*, c.path AS category, fc.confidence, fc.source
+		FROM file_categories fc
+		JOIN categories c ON c.id = fc.category_id AND c.deleted_at IS NULL
+		JOIN file_indices f ON f.id = fc.file_index_id AND f.deleted_at IS NULL
+		WHERE fc.deleted_at IS NULL AND (c.path = ? OR c.path LIKE ?)
+		ORDER BY fc.confidence DESC, f.id
+		LIMIT ? OFFSET ?`, path, sqlutil.EscapeLike(path)+"/%", limit, offset).Scan(&files).Error
+	return files, err
+}
+
+// Assignment returns the category assigned to a file, or nil if it has none.
+func (s *Store) Assignment(fileIndexID uint) (*CategorizedFile, error) {
+	var files []CategorizedFile
+	err := s.db.Raw(`
+		SELECT f.*, c.path AS category, fc.confidence, fc.source
+		FROM file_categories fc
+		JOIN categories c ON c.id = fc.category_id AND c.deleted_at IS NULL
+		JOIN file_indices f ON f.id = fc.file_index_id AND f.deleted_at IS NULL
+		WHERE fc.deleted_at IS NULL AND fc.file_index_id = ?`, fileIndexID).Scan(&files).Error
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	return &files[0], nil
+}
+
+// Correct assigns the category at path to a file by hand. Manual assignments
+// are never overwritten by the classifier.
+func (s *Store) Correct(fileIndexID uint, path string) (*models.FileCategory, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var category models.Category
+	if err := s.db.Where("path = ?", path).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownCategory
+		}
+		return nil, err
+	}
+
+	var file models.FileIndex
+	if err := s.db.First(&file, fileIndexID).Error; err != nil {
+		return nil, err
+	}
+
+	assignment := &models.FileCategory{
+		FileIndexID: fileIndexID,
+		CategoryID:  category.ID,
+		Confidence:  1,
+		Source:      SourceManual,
+	}
+	_, err = s.assign(s.db, assignment)
+	return assignment, err
+}
+
+// assign upserts a file's category, reporting whether it was stored. Only a
+// manual assignment replaces a live manual one, so one made while the
+// classifier was waiting for the model is not overwritten.
+func (s *Store) assign(tx *gorm.DB, assignment *models.FileCategory) (bool, error) {
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_index_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"category_id", "confidence", "source", "prompt_version", "updated_at", "deleted_at"}),
+	}
+	if assignment.Source != SourceManual {
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "file_categories.source <> ? OR file_categories.deleted_at IS NOT NULL", Vars: []interface{}{SourceManual}},
+		}}
+	}
+
+	result := tx.Clauses(onConflict).Create(assignment)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,81 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"prabandh/models"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]string{
+		"finance/invoices":      "finance/invoices",
+		" Finance / Invoices/":  "finance/invoices",
+		"/work//Design   Docs/": "work/design docs",
+		"personal":              "personal",
+	}
+	for in, want := range tests {
+		got, err := NormalizePath(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizePath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "/", " / "} {
+		if _, err := NormalizePath(in); err != ErrInvalidPath {
+			t.Errorf("NormalizePath(%q) error = %v, want ErrInvalidPath", in, err)
+		}
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	roots := buildTree([]categoryCount{
+		{ID: 1, Path: "finance/invoices", Files: 3},
+		{ID: 2, Path: "finance/tax", Files: 2},
+		{ID: 3, Path: "work", Files: 1},
+		{ID: 4, Path: "work/design docs", Files: 4},
+	})
+
+	if len(roots) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(roots))
+	}
+
+	finance := roots[0]
+	if finance.Path != "finance" || finance.ID != 0 || finance.Files != 0 || finance.Total != 5 || len(finance.Children) != 2 {
+		t.Errorf("Unexpected implicit finance node: %+v", finance)
+	}
+
+	work := roots[1]
+	if work.ID != 3 || work.Files != 1 || work.Total != 5 {
+		t.Errorf("Unexpected work node: %+v", work)
+	}
+	if child := work.Children[0]; child.Name != "design docs" || child.Path != "work/design docs" || child.Total != 4 {
+		t.Errorf("Unexpected work child: %+v", child)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	categories := []models.Category{
+		{Path: "finance/invoices"},
+		{Path: "finance/tax"},
+		{Path: "personal/tax"},
+		{Path: "work/design docs"},
+	}
+
+	tests := []struct {
+		answer string
+		want   string
+		ok     bool
+	}{
+		{"finance/tax", "finance/tax", true},
+		{"Finance / Invoices", "finance/invoices", true},
+		{"design docs", "work/design docs", true},
+		{"tax", "", false}, // Ambiguous leaf
+		{"travel", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := match(categories, tt.answer)
+		if ok != tt.ok || got.Path != tt.want {
+			t.Errorf("match(%q) = %q, %v; want %q, %v", tt.answer, got.Path, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package taxonomy
+
+import (
+	"strings"
+)
+
+// Node is a category in the taxonomy tree. Intermediate nodes that were never
+// defined themselves (e.g. "finance" when only "finance/tax" exists) have ID 0.
+type Node struct {
+	ID          uint    `json:"id,omitempty"`
+	Name        string  `json:"name"`
+	Path        string  `json:"path"`
+	Description string  `json:"description,omitempty"`
+	Files       int     `json:"files"` // Files assigned to this category itself
+	Total       int     `json:"total"` // Files in this category and its descendants
+	Children    []*Node `json:"children"`
+}
+
+// categoryCount is a category with the number of files assigned to it.
+type categoryCount struct {
+	ID          uint
+	Path        string
+	Description string
+	Files       int
+}
+
+// buildTree arranges categories ordered by path into a tree and totals the
+// file counts of each subtree.
+func buildTree(counts []categoryCount) []*Node {
+	roots := []*Node{}
+	byPath := make(map[string]*Node)
+
+	for _, c := range counts {
+		var parent *Node
+		segments := strings.Split(c.Path, "/")
+		for i, segment := range segments {
+			path := strings.Join(segments[:i+1], "/")
+			node, ok := byPath[path]
+			if !ok {
+				node = &Node{Name: segment, Path: path, Children: []*Node{}}
+				byPath[path] = node
+				if parent == nil {
+					roots = append(roots, node)
+				} else {
+					parent.Children = append(parent.Children, node)
+				}
+			}
+			parent = node
+		}
+
+		parent.ID = c.ID
+		parent.Description = c.Description
+		parent.Files = c.Files
+	}
+
+	for _, root := range roots {
+		total(root)
+	}
+	return roots
+}
+
+func total(n *Node) int {
+	n.Total = n.Files
+	for _, child := range n.Children {
+		n.Total += total(child)
+	}
+	return n.Total
+}